@@ -5,11 +5,23 @@ import (
 )
 
 func Clean() error {
-	// delete the FileTreeBranch branch
-	if err := core.DeleteBranch(core.FileTreeBranch); err != nil {
+	// delete the stored metadata
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Remove(); err != nil {
 		return err
 	}
 
+	// delete the FileTreeBranch branch
+	if core.BranchExists(core.FileTreeBranch) {
+		if err := core.DeleteBranch(core.FileTreeBranch); err != nil {
+			return err
+		}
+	}
+
 	// delete the .gitattributes file
 	if err := core.DeleteFile(".gitattributes"); err != nil {
 		return err
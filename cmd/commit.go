@@ -13,6 +13,29 @@ func Commit() error {
 		return fmt.Errorf("failed to sync: %w", err)
 	}
 
+	config, err := core.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	store, err := core.OpenStore(config)
+	if err != nil {
+		return err
+	}
+
+	// read the stored metadata before stashing and switching branches, the
+	// main backend keeps it in the work tree that the switch replaces
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	// placeholders are filled in from main here and never saved, so the
+	// stored descriptions keep them for the next commit
+	if err := expandForPublishing(fileTree); err != nil {
+		return err
+	}
+
 	// switch to FileTreeBranch branch
 	currentBranch, err := core.GetCurrentBranch()
 	if err != nil {
@@ -46,26 +69,40 @@ func Commit() error {
 		}
 	}()
 
-	// read filetree.yaml into an in-memory FileTree
-	fileTree, err := core.ReadFileTreeFromYaml("filetree.yaml")
-	if err != nil {
-		return fmt.Errorf("failed to read filetree.yaml: %w", err)
+	// bring in the paths added to main since the branch was created, or there
+	// is nothing to commit their descriptions on
+	if err := core.MergeBranch(core.FileTreeBranch, "main"); err != nil {
+		return err
 	}
 
-	orderedNodes := core.GetDfsOrder(fileTree)
+	// generated READMEs go first so each folder's description stays its latest commit
+	if config.GenerateReadmes {
+		readmes, err := core.GenerateReadmes(fileTree)
+		if err != nil {
+			return fmt.Errorf("failed to generate READMEs: %w", err)
+		}
 
-	for _, node := range orderedNodes {
-		if node.IsDir {
-			if err := core.CommitFolderDescription(node); err != nil {
-				return fmt.Errorf("failed to commit folder: %w", err)
+		for _, readme := range readmes {
+			if err := core.Stage(readme); err != nil {
+				return fmt.Errorf("failed to stage %s: %w", readme, err)
 			}
-		} else {
-			if err := core.CommitFileDescription(node); err != nil {
-				return fmt.Errorf("failed to commit file: %w", err)
+		}
+
+		if len(readmes) > 0 {
+			if err := core.Commit("Generate directory READMEs"); err != nil {
+				return fmt.Errorf("failed to commit READMEs: %w", err)
 			}
 		}
 	}
 
+	orderedNodes := core.GetDfsOrder(fileTree)
+
+	for _, node := range orderedNodes {
+		if err := core.CommitNodeDescription(node); err != nil {
+			return fmt.Errorf("failed to commit %s %s: %w", node.Kind, node.Path, err)
+		}
+	}
+
 	// add the top level commit message for the entire project
 	filename := ".temp_file"
 	if err := core.CreateFile(filename); err != nil {
@@ -80,7 +117,7 @@ func Commit() error {
 		return fmt.Errorf("failed to remove temp file: %w", err)
 	}
 
-	if err := core.StageAndCommit(filename, "project root"); err != nil {
+	if err := core.StageAndCommit(filename, core.RootCommitMessage); err != nil {
 		return fmt.Errorf("failed to commit project root: %w", err)
 	}
 
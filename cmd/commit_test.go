@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unusualPaths are described and committed end to end, each one breaks
+// something when a path goes through git without -z or literal pathspecs
+var unusualPaths = []string{
+	"odd dir/with space.txt",
+	`double"quote.txt`,
+	"line\nbreak.txt",
+	"-dash.txt",
+	"glob[1].txt",
+	"glob1.txt",
+	"odd dir",
+}
+
+// ---------- newTestRepo ----------
+// newTestRepo creates a repository with files on main and makes it the
+// working directory for the rest of the test
+func newTestRepo(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	git(t, "init", "-q", "-b", "main")
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "initial")
+}
+
+// ---------- git ----------
+func git(t *testing.T, args ...string) string {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ---------- answer ----------
+// answer makes the next prompt read reply from stdin
+func answer(t *testing.T, reply string) {
+	t.Helper()
+
+	input := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(input, []byte(reply+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		file.Close()
+	})
+}
+
+func TestInitDescCommitUnusualPaths(t *testing.T) {
+	files := make(map[string]string)
+	for _, p := range unusualPaths {
+		if p != "odd dir" {
+			files[p] = "content of " + p
+		}
+	}
+	newTestRepo(t, files)
+
+	if err := Init(nil); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	descriptions := make(map[string]string)
+	for i, p := range unusualPaths {
+		descriptions[p] = "Fixture number " + string(rune('A'+i))
+		answer(t, "y")
+		// -- keeps the leading dash from being read as a flag
+		if err := Desc([]string{"--", p, descriptions[p]}); err != nil {
+			t.Fatalf("desc %q: %v", p, err)
+		}
+	}
+
+	if err := Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if branch := git(t, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("commit left %s checked out, want main", branch)
+	}
+
+	for _, p := range unusualPaths {
+		subject := git(t, "--literal-pathspecs", "log", "-1", "--format=%s", "gittier", "--", p)
+		if subject != descriptions[p] {
+			t.Errorf("last commit touching %q on gittier = %q, want %q", p, subject, descriptions[p])
+		}
+	}
+}
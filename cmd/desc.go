@@ -1,57 +1,60 @@
 package cmd
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/TyPeterson/Gittier/core"
 )
 
-func Desc(path string, description string, verbose bool) error {
-	path = filepath.Clean(path)
-
-	currentBranch, err := core.GetCurrentBranch()
+func Desc(args []string) error {
+	flags := flag.NewFlagSet("desc", flag.ContinueOnError)
+	pending := flags.Bool("pending", false, "keep the description until the path shows up on main")
+	positional, err := parseFlags(flags, args)
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return err
 	}
-
-	needToStash, err := core.NeedToStash(currentBranch)
-	if err != nil {
-		return fmt.Errorf("failed to check if need to stash: %w", err)
+	if len(positional) != 2 {
+		return errors.New("usage: gittier desc [--pending] <path> <description>")
 	}
+	path, description := filepath.Clean(positional[0]), positional[1]
+	warnPlaceholderLookalikes(path, description)
+	// always show the old description before replacing it
+	verbose := true
 
-	if needToStash {
-		if err := core.Stash(); err != nil {
-			return fmt.Errorf("failed to stash: %w", err)
-		}
-
-		defer func() {
-			if err := core.StashPop(); err != nil {
-				fmt.Println("failed to pop stash")
-			}
-		}()
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
 	}
 
-	if err := core.SwitchToBranch(core.FileTreeBranch); err != nil {
-		return fmt.Errorf("failed to switch to filetree branch: %w", err)
+	// read the existing FileTree into an in-memory representation
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
 	}
 
-	defer func() {
-		if err := core.SwitchToBranch(currentBranch); err != nil {
-			fmt.Println("failed to switch to original branch")
+	node := fileTree.GetNode(path)
+	if node == nil {
+		// a typo should fail rather than wait for a path that never comes
+		if !*pending {
+			return fmt.Errorf("path not found in filetree: %s, use --pending to describe it before it is on main", path)
 		}
-	}()
-
-	// read the existing FileTree into an in-memory representation
-	fileTree, err := core.ReadFileTreeFromYaml("filetree.yaml")
-	if err != nil {
-		return fmt.Errorf("failed to read filetree.yaml: %w", err)
+		if err := core.CheckPlaceholders(description, nil); err != nil {
+			return fmt.Errorf("invalid description for %s: %w", path, err)
+		}
+		return describePending(store, fileTree, path, description, verbose)
+	}
+	if *pending {
+		fmt.Printf("'%s' is already on main, describing it right away\n", path)
 	}
 
-	node, exists := fileTree.Nodes[path]
-	if !exists {
-		return fmt.Errorf("path not found in filetree: %s", path)
+	// a placeholder that can not be filled in would block every commit
+	if err := core.CheckPlaceholders(description, node); err != nil {
+		return fmt.Errorf("invalid description for %s: %w", path, err)
 	}
 
 	// in verbose mode, show the old description
@@ -59,6 +62,12 @@ func Desc(path string, description string, verbose bool) error {
 		fmt.Printf("Current description for '%s': %s\n", path, node.Description)
 	}
 
+	// annotations on main win again the next time sync reads them
+	if node.SourceManaged {
+		source := core.AnnotationSource(node)
+		fmt.Printf("Warning: the description of '%s' comes from its annotation in %s on main, sync puts the annotation back the next time that file changes\n", path, source)
+	}
+
 	// if the node already has a description, ask for confirmation
 	if node.Description != "" {
 		fmt.Printf("Do you want to overwrite the existing description? (y/n): ")
@@ -70,20 +79,39 @@ func Desc(path string, description string, verbose bool) error {
 		}
 	}
 
-	node.Description = description
+	node.SetDescription(description)
 
-	// write the updated FileTree back to filetree.yaml
-	if err := core.WriteFileTreeToYaml(fileTree, "filetree.yaml"); err != nil {
-		return fmt.Errorf("failed to write updated filetree.yaml: %w", err)
+	// save the updated FileTree back to the configured store
+	if err := store.Save(fileTree, fmt.Sprintf("Describe %s", path)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
 	}
 
 	fmt.Printf("Updated description for '%s'\n", path)
+	return nil
+}
 
-	// stage and commit filetree.yaml to FileTreeBranch
-	if err := core.StageAndCommit("filetree.yaml", "Initialize filetree.yaml"); err != nil {
-		fmt.Println("failed to stage and commit filetree.yaml")
+// ---------- describePending ----------
+// describePending keeps the description of a path that is not on main yet
+// until sync finds it there
+func describePending(store core.Store, fileTree *core.FileTree, path, description string, verbose bool) error {
+	if existing := fileTree.GetPending(path); existing != nil {
+		if verbose {
+			fmt.Printf("Current pending description for '%s': %s\n", path, existing.Description)
+		}
+		if !confirm("Do you want to overwrite the existing pending description?") {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	if err := fileTree.AddPending(path, description, time.Now().UTC().Truncate(time.Second)); err != nil {
 		return err
 	}
 
+	if err := store.Save(fileTree, fmt.Sprintf("Describe pending %s", path)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	fmt.Printf("'%s' is not on main yet, its description will be attached once sync finds it\n", path)
 	return nil
 }
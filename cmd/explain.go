@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Explain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: gittier explain <path>")
+	}
+	p := cleanTreePath(args[0])
+
+	config, err := core.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := core.OpenStore(config)
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	node := fileTree.GetNode(p)
+	if node == nil {
+		if pending := fileTree.GetPending(p); pending != nil {
+			fmt.Printf("%s is not on main yet, its pending description is: %s\n", p, pending.Description)
+			return nil
+		}
+		return fmt.Errorf("path not found in filetree: %s", p)
+	}
+
+	fmt.Println(p)
+	if core.IsDescribed(node) {
+		fmt.Printf("  Description: %s\n", core.CommitTitle(node.Description))
+	}
+	switch {
+	case node.Rule != "":
+		fmt.Printf("  Source: the rule %q in %s\n", node.Rule, core.ConfigFile)
+	case node.Pack != "":
+		pack, pattern, _ := strings.Cut(node.Pack, " ")
+		fmt.Printf("  Source: the rule %q of the %s convention pack, 'gittier suggest' may have something better\n", pattern, pack)
+	case !core.IsDescribed(node):
+		fmt.Println("  Source: none, the path has no description")
+	case node.SourceManaged:
+		fmt.Printf("  Source: the annotation in %s on main\n", core.AnnotationSource(node))
+	case node.CopiedFrom != "":
+		fmt.Printf("  Source: copied along with the content of %s\n", node.CopiedFrom)
+	default:
+		fmt.Println("  Source: written for this path, rules do not apply")
+	}
+
+	// every rule that matches, in the order they are tried
+	rules := config.DescriptionRules()
+	var matching []core.Rule
+	for _, rule := range rules {
+		if rule.Matches(node) {
+			matching = append(matching, rule)
+		}
+	}
+	if len(matching) == 0 {
+		fmt.Println("  No rule matches this path")
+	} else {
+		fmt.Println("  Matching rules, the first one wins:")
+		for _, rule := range matching {
+			text, err := rule.Describe(node)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("    %s: %s\n", rule.Pattern, text)
+		}
+	}
+
+	// the config may have changed since the rules were last applied
+	if node.Rule == "" && core.IsDescribed(node) {
+		return nil
+	}
+	outdated := node.Rule != ""
+	if len(matching) > 0 {
+		text, _ := matching[0].Describe(node)
+		outdated = matching[0].Pattern != node.Rule || text != node.Description
+	}
+	if outdated {
+		fmt.Println("  The rules changed since the last sync, run 'gittier sync' to apply them")
+	}
+	return nil
+}
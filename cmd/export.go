@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "", "output format: "+strings.Join(core.ExportFormats, ", "))
+	output := flags.String("output", "", "file to write instead of stdout")
+	expand := flags.Bool("expand", false, "fill in ${placeholders} as commit publishes them, the export can then not be imported without losing them")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("usage: gittier export [--format %s] [--output file] [--expand]", strings.Join(core.ExportFormats, "|"))
+	}
+
+	// infer the format from the output file when not given
+	if *format == "" && *output != "" {
+		*format = core.FormatFromFilename(*output)
+	}
+	if *format == "" {
+		*format = "json"
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	if *expand {
+		if err := expandForDisplay(fileTree); err != nil {
+			return err
+		}
+	}
+
+	if *output == "" {
+		return core.ExportFileTree(fileTree, *format, os.Stdout)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *output, err)
+	}
+	defer file.Close()
+
+	if err := core.ExportFileTree(fileTree, *format, file); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %s to %s\n", store.Name(), *output)
+	return nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"flag"
+)
+
+// ---------- parseFlags ----------
+// parseFlags parses args allowing flags to come after positional arguments,
+// so both 'tree cmd --depth 1' and 'tree --depth 1 cmd' work
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		// everything after a literal -- is positional
+		consumed := len(args) - flags.NArg()
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, flags.Args()...), nil
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Import(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "input format: "+strings.Join(core.ExportFormats, ", ")+" (default from file extension)")
+	merge := flags.Bool("merge", false, "only fill in paths that have no description yet")
+	dryRun := flags.Bool("dry-run", false, "report what would change without saving")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return errors.New("usage: gittier import [--format json|csv|toml] [--merge] [--dry-run] <file>")
+	}
+	filename := positional[0]
+
+	if *format == "" {
+		*format = core.FormatFromFilename(filename)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	records, err := core.ParseRecords(*format, file)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	// validate against the latest structure of main, syncing saves so a dry
+	// run checks the tree as it was last synced instead
+	if *dryRun {
+		fmt.Println("Dry run, comparing against the last synced file tree")
+	} else if err := Sync(); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	report, err := core.ImportRecords(fileTree, records, *merge)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		warnPlaceholderLookalikes(record.Path, record.Description)
+	}
+
+	printPaths := func(title string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		fmt.Printf("%s (%d):\n", title, len(paths))
+		for _, path := range paths {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	printPaths("Unknown paths, not in the file tree", report.Unknown)
+	printPaths("Missing paths, not in the import", report.Missing)
+	if *merge {
+		printPaths("Skipped, already described", report.Skipped)
+	}
+	fmt.Printf("%d updated, %d unchanged, %d skipped, %d unknown, %d missing\n",
+		len(report.Updated), len(report.Unchanged), len(report.Skipped), len(report.Unknown), len(report.Missing))
+
+	if *dryRun || len(report.Updated) == 0 {
+		return nil
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Import descriptions from %s", filename)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	return nil
+}
@@ -2,69 +2,59 @@ package cmd
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/TyPeterson/Gittier/core"
 )
 
 // ---------- cmdInit ----------
-func Init() error {
+func Init(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	packNames := flags.String("pack", "", "comma-separated convention packs to pre-fill descriptions with, e.g. go or go,node")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
 	// ensure the current directory is a git repo
 	if !core.IsGitRepo() {
 		return errors.New("Not a git repository")
 	}
 
-	// ensure the project is not already initialized
-	if core.BranchExists(core.FileTreeBranch) {
-		return errors.New("Project already initialized, run 'gittier update' instead")
+	// read the packs up front so a typo fails before anything is created
+	var rules []core.Rule
+	for _, name := range strings.Split(*packNames, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		pack, err := core.LoadPack(name)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, pack.Rules...)
 	}
 
-	// save the current branch name
-	currentBranch, err := core.GetCurrentBranch()
-
+	config, err := core.LoadConfig()
 	if err != nil {
-		fmt.Println("failed to get current branch")
 		return err
 	}
-
-	// create FileTreeBranch
-	if err := core.CreateBranch(core.FileTreeBranch); err != nil {
-		return fmt.Errorf("failed to create filetree branch: %w", err)
-	}
-
-	// stash any changes if needed
-	needToStash, err := core.NeedToStash(currentBranch)
+	store, err := core.OpenStore(config)
 	if err != nil {
-		fmt.Println("failed to check if need to stash")
 		return err
 	}
 
-	if needToStash {
-		if err := core.Stash(); err != nil {
-			fmt.Println("failed to stash")
-			return err
-		}
-
-		defer func() {
-			if err := core.StashPop(); err != nil {
-				fmt.Println("failed to pop stash")
-			}
-
-		}()
-	}
-
-	// switch to FileTreeBranch
-	if err := core.SwitchToBranch(core.FileTreeBranch); err != nil {
-		fmt.Println("failed to switch to filetree branch")
-		return err
+	// ensure the project is not already initialized
+	if store.Exists() {
+		return errors.New("Project already initialized, run 'gittier sync' instead")
 	}
 
-	// defer switching back to current branch
-	defer func() {
-		if err := core.SwitchToBranch(currentBranch); err != nil {
-			fmt.Println("failed to switch back to current branch")
+	// create FileTreeBranch, which commit uses to show the descriptions
+	if !core.BranchExists(core.FileTreeBranch) {
+		if err := core.CreateBranch(core.FileTreeBranch); err != nil {
+			return fmt.Errorf("failed to create filetree branch: %w", err)
 		}
-	}()
+	}
 
 	// get FileTree from main branch's ls-tree
 	fileTree, err := core.GetFileTreeFromBranch("main")
@@ -73,18 +63,35 @@ func Init() error {
 		return err
 	}
 
-	// write FileTree to filetree.yaml
-	if err := core.WriteFileTreeToYaml(fileTree, "filetree.yaml"); err != nil {
-		fmt.Println("failed to write filetree.yaml")
+	// start from the annotations already on main
+	annotated, err := fileTree.ApplyAnnotations(core.GetDfsOrder(fileTree))
+	if err != nil {
 		return err
 	}
 
-	// stage and commit filetree.yaml
-	if err := core.StageAndCommit("filetree.yaml", "Initialize filetree.yaml"); err != nil {
-		fmt.Println("failed to stage and commit filetree.yaml and .gitattributes")
+	// packs only fill in what annotations left empty
+	conventional, err := fileTree.ApplyRules(rules)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("Gittier project initialized")
+	// config rules cover whatever is still undescribed
+	if _, err := fileTree.SyncRules(config.DescriptionRules(), nil); err != nil {
+		return err
+	}
+
+	// save FileTree to the configured store
+	if err := store.Save(fileTree, "Initialize filetree.yaml"); err != nil {
+		fmt.Printf("failed to save %s\n", store.Name())
+		return err
+	}
+
+	if len(annotated) > 0 {
+		fmt.Printf("Described %d path(s) from their annotations\n", len(annotated))
+	}
+	if len(conventional) > 0 {
+		fmt.Printf("Described %d conventional path(s) from %s\n", len(conventional), *packNames)
+	}
+	fmt.Printf("Gittier project initialized, descriptions are stored in %s\n", store.Name())
 	return nil
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Mv(args []string) error {
+	flags := flag.NewFlagSet("mv", flag.ContinueOnError)
+	force := flags.Bool("force", false, "overwrite an existing destination nobody has described")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: gittier mv [--force] <src> <dst>")
+	}
+	src, dst := cleanTreePath(positional[0]), cleanTreePath(positional[1])
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	if src == "" || fileTree.GetNode(src) == nil {
+		return fmt.Errorf("path not found in filetree: %s (run 'gittier sync' if it was added recently)", src)
+	}
+
+	// like git mv, moving onto a folder moves into it
+	if target := fileTree.GetNode(dst); target != nil && target.IsDir {
+		dst = path.Join(dst, path.Base(src))
+	}
+	if dst == src || fileTree.IsAncestor(src, dst) {
+		return fmt.Errorf("cannot move %s into itself", src)
+	}
+
+	if fileTree.HasNode(dst) {
+		if described := fileTree.DescribedPaths(dst); len(described) > 0 {
+			return fmt.Errorf("refusing to overwrite described path(s): %s", strings.Join(described, ", "))
+		}
+		if !*force {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", dst)
+		}
+	}
+
+	if err := core.GitMove(src, dst, *force); err != nil {
+		return err
+	}
+	if err := fileTree.MoveNode(src, dst); err != nil {
+		return err
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Move %s to %s", src, dst)); err != nil {
+		return fmt.Errorf("failed to save %s after moving the files, the move is staged but its descriptions did not follow: %w", store.Name(), err)
+	}
+
+	fmt.Printf("Moved %s -> %s, commit the move to main and run 'gittier sync'\n", src, dst)
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Pending(args []string) error {
+	flags := flag.NewFlagSet("pending", flag.ContinueOnError)
+	drop := flags.Bool("drop", false, "forget the pending descriptions of the given paths")
+	paths, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	if !*drop {
+		if len(paths) > 0 {
+			return fmt.Errorf("usage: gittier pending [--drop <path>...]")
+		}
+		if len(fileTree.Pending) == 0 {
+			fmt.Println("No pending descriptions")
+			return nil
+		}
+		for _, pending := range fileTree.Pending {
+			fmt.Printf("%s (since %s): %s\n", pending.Path, pending.Added.Format("2006-01-02"), core.CommitTitle(pending.Description))
+		}
+		return nil
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("usage: gittier pending --drop <path>...")
+	}
+	for i, p := range paths {
+		paths[i] = cleanTreePath(p)
+	}
+	count, err := fileTree.DropPending(paths)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Drop pending description of %s", strings.Join(paths, ", "))); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	fmt.Printf("Dropped %d pending description(s)\n", count)
+	return nil
+}
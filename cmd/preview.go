@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Preview(args []string) error {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	outDir := flags.String("html", "", "directory to write the HTML preview into")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *outDir == "" {
+		return errors.New("usage: gittier preview --html <dir>")
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	// show the descriptions as commit would publish them
+	if err := expandForDisplay(fileTree); err != nil {
+		return err
+	}
+
+	repo, err := core.GetRepoName()
+	if err != nil {
+		return err
+	}
+
+	if err := core.WritePreview(fileTree, repo, *outDir); err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+
+	fmt.Printf("Preview written to %s\n", filepath.Join(*outDir, "index.html"))
+	return nil
+}
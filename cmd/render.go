@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Render(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	format := flags.String("format", "markdown", "output format: markdown, dot or mermaid")
+	style := flags.String("style", "tree", "markdown style: tree (ASCII) or list (nested list)")
+	depth := flags.Int("depth", 0, "maximum depth to render, 0 for no limit")
+	hideUndescribed := flags.Bool("hide-undescribed", false, "leave out paths without a description")
+	root := flags.String("root", "", "only render the subtree below this directory")
+	dirsOnly := flags.Bool("dirs-only", false, "leave out files")
+	inject := flags.String("inject", "", "replace the content between the gittier markers in this file")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("usage: gittier render [--format markdown|dot|mermaid] [--style tree|list] [--depth n] [--hide-undescribed] [--root dir] [--dirs-only] [--inject file]")
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	// show the descriptions as commit would publish them, an injected file
+	// is published on main and must not carry a broken placeholder
+	expand := expandForDisplay
+	if *inject != "" {
+		expand = expandForPublishing
+	}
+	if err := expand(fileTree); err != nil {
+		return err
+	}
+
+	opts := core.RenderOptions{
+		Style:           *style,
+		MaxDepth:        *depth,
+		HideUndescribed: *hideUndescribed,
+		Root:            cleanTreePath(*root),
+		DirsOnly:        *dirsOnly,
+	}
+
+	var output string
+	switch *format {
+	case "markdown", "md":
+		output, err = core.RenderMarkdown(fileTree, opts)
+	case "dot":
+		output, err = core.RenderDot(fileTree, opts)
+	case "mermaid":
+		output, err = core.RenderMermaid(fileTree, opts)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *inject == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	content, err := os.ReadFile(*inject)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", *inject, err)
+	}
+
+	updated, err := core.InjectBetweenMarkers(string(content), output)
+	if err != nil {
+		return fmt.Errorf("failed to inject into %s: %w", *inject, err)
+	}
+
+	if updated == string(content) {
+		fmt.Printf("%s is already up to date\n", *inject)
+		return nil
+	}
+
+	if err := os.WriteFile(*inject, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *inject, err)
+	}
+
+	fmt.Printf("Updated the file tree in %s\n", *inject)
+	return nil
+}
+
+// ---------- expandForDisplay ----------
+// expandForDisplay fills in placeholders the way commit would publish them,
+// leaving a description that does not expand as written with a warning
+// rather than failing a command that only shows it
+func expandForDisplay(fileTree *core.FileTree) error {
+	problems, err := core.ExpandPlaceholders(fileTree, "main")
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %v, shown as written\n", problem)
+	}
+	return nil
+}
+
+// ---------- expandForPublishing ----------
+// expandForPublishing fills in placeholders and fails on any description
+// that does not expand, so broken text is never published
+func expandForPublishing(fileTree *core.FileTree) error {
+	for _, node := range core.GetDfsOrder(fileTree) {
+		if core.IsDescribed(node) {
+			warnPlaceholderLookalikes(node.Path, node.Description)
+		}
+	}
+
+	problems, err := core.ExpandPlaceholders(fileTree, "main")
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("cannot expand descriptions: %w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// ---------- warnPlaceholderLookalikes ----------
+func warnPlaceholderLookalikes(path, description string) {
+	for _, lookalike := range core.PlaceholderLookalikes(description) {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s is published as written, write $%s to fill it in\n", path, lookalike, lookalike)
+	}
+}
+
+// ---------- cleanTreePath ----------
+// cleanTreePath turns a path given on the command line into a FileTree key,
+// with "" meaning the repository root
+func cleanTreePath(path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if path == "." {
+		return ""
+	}
+	return path
+}
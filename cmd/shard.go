@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Shard(depthArg string) error {
+	depth, err := strconv.Atoi(depthArg)
+	if err != nil || depth < 0 {
+		return fmt.Errorf("invalid shard depth: %s", depthArg)
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	// every shard is read before the layout changes so nothing is dropped
+	if err := fileTree.SetShardDepth(depth); err != nil {
+		return err
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Shard filetree.yaml at depth %d", depth)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	if depth == 0 {
+		fmt.Println("File tree stored in a single filetree.yaml")
+	} else {
+		fmt.Printf("File tree sharded into %s/ at depth %d\n", core.ShardDir("filetree.yaml"), depth)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Stale(args []string) error {
+	flags := flag.NewFlagSet("stale", flag.ContinueOnError)
+	keep := flags.Bool("confirm", false, "keep the descriptions of the given paths and clear their stale flag")
+	paths, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	if !*keep {
+		if len(paths) > 0 {
+			return fmt.Errorf("usage: gittier stale [--confirm <path>...]")
+		}
+		return listStale(fileTree)
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("usage: gittier stale --confirm <path>...")
+	}
+	for i, p := range paths {
+		p = cleanTreePath(p)
+		node := fileTree.GetNode(p)
+		if node == nil {
+			return fmt.Errorf("path not found in filetree: %s", p)
+		}
+		if !core.IsDescribed(node) {
+			return fmt.Errorf("%s has no description to confirm", p)
+		}
+		node.ConfirmDescription()
+		paths[i] = p
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Confirm description of %s", strings.Join(paths, ", "))); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	fmt.Printf("Confirmed %d description(s)\n", len(paths))
+	return nil
+}
+
+// ---------- listStale ----------
+func listStale(fileTree *core.FileTree) error {
+	var stale []*core.PathNode
+	for _, node := range core.GetDfsOrder(fileTree) {
+		if node.Stale {
+			stale = append(stale, node)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].Path < stale[j].Path
+	})
+
+	if len(stale) == 0 {
+		fmt.Println("No stale descriptions")
+		return nil
+	}
+
+	for _, node := range stale {
+		fmt.Printf("%s: %s\n", node.Path, core.CommitTitle(node.Description))
+	}
+	fmt.Println("\nUpdate them with 'gittier desc <path> <description>' or keep them with 'gittier stale --confirm <path>'")
+	return nil
+}
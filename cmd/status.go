@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Status() error {
+	config, err := core.LoadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := core.OpenStore(config)
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	// compare the synced commit with main
+	mainHash, err := core.GetCommitHash("main")
+	if err != nil {
+		return err
+	}
+	if fileTree.CommitHash == mainHash {
+		fmt.Printf("Synced with main at %s\n", shortHash(mainHash))
+	} else {
+		fmt.Printf("Synced at %s, main is at %s, run 'gittier sync'\n", shortHash(fileTree.CommitHash), shortHash(mainHash))
+	}
+
+	described, stale := 0, 0
+	nodes := core.GetDfsOrder(fileTree)
+	for _, node := range nodes {
+		if core.IsDescribed(node) {
+			described++
+		}
+		if node.Stale {
+			stale++
+		}
+	}
+	fmt.Printf("%d of %d path(s) described\n", described, len(nodes))
+	if stale > 0 {
+		fmt.Printf("%d possibly stale description(s), run 'gittier stale' to review them\n", stale)
+	}
+	if len(fileTree.Tombstones) > 0 {
+		fmt.Printf("%d tombstone(s) of deleted paths\n", len(fileTree.Tombstones))
+	}
+	if len(fileTree.Pending) > 0 {
+		fmt.Printf("%d pending description(s) waiting for their path\n", len(fileTree.Pending))
+	}
+
+	// pending descriptions nobody followed up on are likely plans that changed
+	now := time.Now()
+	for _, pending := range fileTree.OverduePending(config.PendingMaxAge(), now) {
+		days := int(now.Sub(pending.Added).Hours() / 24)
+		fmt.Printf("Warning: %s has been pending for %d days, drop it with 'gittier pending --drop %s' if it is not coming\n", pending.Path, days, pending.Path)
+	}
+	return nil
+}
+
+// ---------- shortHash ----------
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
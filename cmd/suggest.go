@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Suggest(args []string) error {
+	flags := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	list := flags.Bool("list", false, "only print the suggestions")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return fmt.Errorf("usage: gittier suggest [path] [--list]")
+	}
+
+	root := ""
+	if len(positional) > 0 {
+		root = cleanTreePath(positional[0])
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+	if root != "" && !fileTree.HasNode(root) {
+		return fmt.Errorf("path not found in filetree: %s", root)
+	}
+
+	suggestions, err := core.Suggest(fileTree, root)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		fmt.Println("No suggestions, every path either has a description or nothing to go on")
+		return nil
+	}
+
+	if *list {
+		for _, suggestion := range suggestions {
+			fmt.Printf("%s: %s (from %s)\n", suggestion.Path, suggestion.Description, suggestion.Source)
+		}
+		return nil
+	}
+
+	accepted := reviewSuggestions(fileTree, suggestions, bufio.NewReader(os.Stdin))
+	if accepted == 0 {
+		fmt.Println("No suggestions accepted")
+		return nil
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Accept %d suggested description(s)", accepted)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	fmt.Printf("Accepted %d of %d suggestion(s)\n", accepted, len(suggestions))
+	return nil
+}
+
+// ---------- reviewSuggestions ----------
+// reviewSuggestions asks about every suggestion in turn and describes the
+// accepted paths, returning how many there were. Quitting or running out of
+// input keeps what was accepted so far
+func reviewSuggestions(fileTree *core.FileTree, suggestions []core.Suggestion, input *bufio.Reader) int {
+	accepted := 0
+	for i, suggestion := range suggestions {
+		fmt.Printf("\n[%d/%d] %s (from %s)\n  %s\n", i+1, len(suggestions), suggestion.Path, suggestion.Source, suggestion.Description)
+
+		for {
+			fmt.Print("Accept? [y]es, [n]o, [e]dit, [q]uit: ")
+			answer, err := readAnswer(input)
+			if err != nil {
+				return accepted
+			}
+
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				if !validSuggestion(fileTree, suggestion.Path, suggestion.Description) {
+					continue
+				}
+				fileTree.UpdateNodeDescription(suggestion.Path, suggestion.Description)
+				accepted++
+			case "n", "no", "":
+			case "e", "edit":
+				fmt.Print("Description: ")
+				description, err := readAnswer(input)
+				if err != nil {
+					return accepted
+				}
+				if description == "" {
+					fmt.Println("Skipped")
+					break
+				}
+				if !validSuggestion(fileTree, suggestion.Path, description) {
+					continue
+				}
+				fileTree.UpdateNodeDescription(suggestion.Path, description)
+				accepted++
+			case "q", "quit":
+				return accepted
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return accepted
+}
+
+// ---------- validSuggestion ----------
+// validSuggestion reports whether description can be published for p,
+// printing why not so the user can answer again
+func validSuggestion(fileTree *core.FileTree, p, description string) bool {
+	warnPlaceholderLookalikes(p, description)
+	if err := core.CheckPlaceholders(description, fileTree.GetNode(p)); err != nil {
+		fmt.Printf("Invalid description: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// ---------- readAnswer ----------
+func readAnswer(input *bufio.Reader) (string, error) {
+	line, err := input.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
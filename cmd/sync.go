@@ -2,87 +2,307 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/TyPeterson/Gittier/core"
 )
 
 func Sync() error {
 
-	currentBranch, err := core.GetCurrentBranch()
+	config, err := core.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return err
+	}
+	store, err := core.OpenStore(config)
+	if err != nil {
+		return err
 	}
 
-	needToStash, err := core.NeedToStash(currentBranch)
+	// read the stored metadata into a FileTree
+	fileTree, err := store.Load()
 	if err != nil {
-		return fmt.Errorf("failed to check if need to stash: %w", err)
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
 	}
 
-	if needToStash {
-		if err := core.Stash(); err != nil {
-			return fmt.Errorf("failed to stash: %w", err)
-		}
+	// moves made with gittier mv that main does not have yet are put back
+	// while syncing and made again afterwards
+	landed, waiting := fileTree.SplitMoves("main")
+
+	// after a force-push or history rewrite there is nothing to diff against
+	if !core.CommitExists(fileTree.CommitHash) {
+		return recoverSync(store, config, fileTree, waiting)
+	}
 
-		defer func() {
-			if err := core.StashPop(); err != nil {
-				fmt.Println("failed to pop stash")
-			}
-		}()
+	// get diff between commit hash of filetree.yaml and main
+	diffOutput, err := core.GetDiffOutput(fileTree.CommitHash, config.RenameThreshold, config.FindCopiesHarder)
+	if err != nil {
+		return fmt.Errorf("failed to get diff output: %w", err)
 	}
 
-	if err := core.SwitchToBranch(core.FileTreeBranch); err != nil {
-		return fmt.Errorf("failed to switch to filetree branch: %w", err)
+	// descriptions written into commit messages since the last sync
+	trailers, malformed, err := core.ReadDescribeTrailers(fileTree.CommitHash, "main")
+	if err != nil {
+		return err
 	}
 
-	defer func() {
-		if err := core.SwitchToBranch(currentBranch); err != nil {
-			fmt.Println("failed to switch to original branch")
-		}
-	}()
+	// if diffOutput is empty, no changes have been made to the file tree and we can return
+	if len(diffOutput) == 0 && len(trailers) == 0 && len(malformed) == 0 {
+		return syncRules(store, config, fileTree)
+	}
+	if err := fileTree.RevertMoves(waiting); err != nil {
+		return err
+	}
 
-	// read filetree.yaml into a FileTree
-	oldFileTree, err := core.ReadFileTreeFromYaml("filetree.yaml")
+	// read only the folders of main whose tree id changed since the last sync
+	changedTree, err := core.ReadChangedTree(fileTree, "main")
 	if err != nil {
-		return fmt.Errorf("failed to read filetree.yaml: %w", err)
+		return fmt.Errorf("failed to read changed folders of main: %w", err)
 	}
 
-	// get diff between commit hash of filetree.yaml and main
-	diffOutput, err := core.GetDiffOutput(oldFileTree.CommitHash)
+	syncedFrom := fileTree.CommitHash
+
+	// move descriptions along with renames and copies
+	report, err := core.ProcessGitDiff(fileTree, diffOutput, changedTree.Tree, core.SyncOptions{Confirm: confirm, Moves: landed})
 	if err != nil {
-		return fmt.Errorf("failed to get diff output: %w", err)
+		return fmt.Errorf("failed to process git diff: %w", err)
 	}
 
-	// if diffOutput is empty, no changes have been made to the file tree and we can return
-	if len(diffOutput) == 0 || (len(diffOutput) == 1 && diffOutput[0] == "") {
-		fmt.Println("No changes to sync")
-		return nil
+	moved := fileTree.MovedNodes(report)
+
+	// bring object ids and the entries of the changed folders up to date
+	touched, restored := core.ApplyChangedTree(fileTree, changedTree)
+	report.Restored = append(report.Restored, restored...)
+	if err := fileTree.DateTombstones(syncedFrom); err != nil {
+		return err
+	}
+
+	// descriptions written before their path existed
+	attached, held := fileTree.AttachPending()
+
+	// trailers come last, they were written after everything above
+	described, unknown := fileTree.ApplyDescribeTrailers(trailers)
+
+	// annotations on main override whatever the tree says
+	annotated, err := fileTree.ApplyAnnotations(touched)
+	if err != nil {
+		return err
 	}
 
-	// apply changes from Sync to oldFileTree
-	updatedFileTree, err := core.ProcessGitDiff(oldFileTree, diffOutput)
+	fileTree.Moves = nil
+	dropped := fileTree.ReplayMoves(waiting)
+
+	// rules describe what is still undescribed once every path is in place,
+	// only the paths that changed need them unless the rules changed too
+	derived, err := fileTree.SyncRules(config.DescriptionRules(), append(moved, touched...))
 	if err != nil {
-		return fmt.Errorf("failed to process git diff: %w", err)
+		return err
+	}
+
+	// flag descriptions whose content changed too much since they were written
+	stale, err := core.FlagStaleNodes(touched, config.StaleThreshold)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Save(fileTree, fmt.Sprintf("Sync filetree.yaml to %s", fileTree.CommitHash)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	for _, move := range landed {
+		fmt.Printf("Moved %s -> %s as recorded by gittier mv\n", move.From, move.To)
+	}
+	printDiffReport(report, fileTree)
+	printAttached(attached, held)
+	printTrailers(described, unknown, malformed)
+	printAnnotated(annotated)
+	printDerived(derived)
+	printDropped(dropped)
+	printStale(stale)
+	fmt.Println("File tree updated")
+	return nil
+}
+
+// ---------- recoverSync ----------
+// recoverSync rebuilds the tree from main and matches the old descriptions
+// by path and content when the commit they were synced against is gone
+func recoverSync(store core.Store, config *core.Config, oldFileTree *core.FileTree, waiting []*core.Move) error {
+	fmt.Printf("Commit %s is no longer in the repository, matching descriptions against main instead\n", oldFileTree.CommitHash)
+	if err := oldFileTree.RevertMoves(waiting); err != nil {
+		return err
 	}
 
-	// get current structure of main branch FileTree
 	currentFileTree, err := core.GetFileTreeFromBranch("main")
 	if err != nil {
 		return fmt.Errorf("failed to get file tree from ls-tree: %w", err)
 	}
 
-	// arrange the updatedFileTree to match the DFS order of the currentFileTree before writing to yaml
-	syncedFileTree := core.SyncFileTree(updatedFileTree, currentFileTree)
+	syncedFileTree, report := core.ReconcileFileTree(oldFileTree, currentFileTree, config.RenameThreshold)
+	attached, held := syncedFileTree.AttachPending()
+	dropped := syncedFileTree.ReplayMoves(waiting)
+
+	annotated, err := syncedFileTree.ApplyAnnotations(core.GetDfsOrder(syncedFileTree))
+	if err != nil {
+		return err
+	}
+
+	syncedFileTree.RulesHash = ""
+	derived, err := syncedFileTree.SyncRules(config.DescriptionRules(), nil)
+	if err != nil {
+		return err
+	}
+
+	stale, err := core.FlagStaleNodes(core.GetDfsOrder(syncedFileTree), config.StaleThreshold)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Save(syncedFileTree, fmt.Sprintf("Rebuild filetree.yaml against %s", syncedFileTree.CommitHash)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
 
-	if err := core.WriteFileTreeToYaml(syncedFileTree, "filetree.yaml"); err != nil {
-		return fmt.Errorf("failed to write file tree to yaml: %w", err)
+	fmt.Printf("Kept %d description(s) at the same path\n", report.ByPath)
+	for _, move := range report.ByObject {
+		fmt.Printf("Matched %s -> %s (identical content)\n", move.From, move.To)
 	}
+	for _, move := range report.BySimilarity {
+		fmt.Printf("Matched %s -> %s (%d%% similar)\n", move.From, move.To, move.Score)
+	}
+	for _, move := range report.ByFolder {
+		fmt.Printf("Matched folder %s -> %s\n", move.From, move.To)
+	}
+	for _, p := range report.Ambiguous {
+		fmt.Printf("Could not carry over %s: more than one path matches equally well, kept as a tombstone\n", p)
+	}
+	printRestored(report.Restored)
+	for _, p := range report.Lost {
+		fmt.Printf("Could not carry over %s: no matching path on main, kept as a tombstone\n", p)
+	}
+	printAttached(attached, held)
+	printAnnotated(annotated)
+	printDerived(derived)
+	printDropped(dropped)
+	printStale(stale)
+
+	fmt.Println("File tree rebuilt")
+	return nil
+}
 
-	// stage and commit filetree.yaml to FileTreeBranch
-	if err := core.StageAndCommit("filetree.yaml", "Initialize filetree.yaml"); err != nil {
-		fmt.Println("failed to stage and commit filetree.yaml")
+// ---------- syncRules ----------
+// syncRules brings rule descriptions up to date when main itself has not
+// changed but the rules in the config were edited
+func syncRules(store core.Store, config *core.Config, fileTree *core.FileTree) error {
+	rulesHash := fileTree.RulesHash
+	derived, err := fileTree.SyncRules(config.DescriptionRules(), nil)
+	if err != nil {
 		return err
 	}
+	if fileTree.RulesHash == rulesHash {
+		fmt.Println("No changes to sync")
+		return nil
+	}
 
+	// saved even when no description changed, so the next sync does not go
+	// through every path again
+	if err := store.Save(fileTree, "Apply description rules"); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	printDerived(derived)
 	fmt.Println("File tree updated")
 	return nil
 }
+
+// ---------- printRestored ----------
+func printRestored(restored []core.PathMove) {
+	for _, move := range restored {
+		if move.From == move.To {
+			fmt.Printf("Restored the description of %s\n", move.To)
+		} else {
+			fmt.Printf("Restored the description of %s from deleted %s\n", move.To, move.From)
+		}
+	}
+}
+
+// ---------- printAttached ----------
+func printAttached(attached []string, held []error) {
+	for _, p := range attached {
+		fmt.Printf("Attached the pending description of %s\n", p)
+	}
+	for _, err := range held {
+		fmt.Printf("Warning: kept the pending description of %v, replace it with 'gittier pending --drop' and 'gittier desc'\n", err)
+	}
+}
+
+// ---------- printTrailers ----------
+func printTrailers(described, unknown []core.DescribeTrailer, malformed []string) {
+	for _, trailer := range described {
+		fmt.Printf("Described %s from commit %s\n", trailer.Path, shortHash(trailer.Commit))
+	}
+	for _, trailer := range unknown {
+		fmt.Printf("Ignored the %s trailer of commit %s: %s is not on main\n", core.DescribeTrailerKey, shortHash(trailer.Commit), trailer.Path)
+	}
+	for _, problem := range malformed {
+		fmt.Printf("Ignored a %s trailer of commit %s\n", core.DescribeTrailerKey, problem)
+	}
+}
+
+// ---------- printAnnotated ----------
+func printAnnotated(annotated []string) {
+	for _, p := range annotated {
+		fmt.Printf("Described %s from its annotation\n", p)
+	}
+}
+
+// ---------- printDerived ----------
+func printDerived(derived []string) {
+	if len(derived) > 0 {
+		fmt.Printf("Updated %d description(s) from rules, 'gittier explain <path>' shows which rule\n", len(derived))
+	}
+}
+
+// ---------- printDropped ----------
+func printDropped(dropped []*core.Move) {
+	for _, move := range dropped {
+		fmt.Printf("Dropped the recorded move of %s to %s, main no longer matches it\n", move.From, move.To)
+	}
+}
+
+// ---------- printStale ----------
+func printStale(stale []string) {
+	for _, p := range stale {
+		fmt.Printf("Description of %s is possibly stale\n", p)
+	}
+	if len(stale) > 0 {
+		fmt.Println("Run 'gittier stale' to review them")
+	}
+}
+
+// ---------- confirm ----------
+func confirm(question string) bool {
+	fmt.Printf("%s (y/n): ", question)
+	var response string
+	fmt.Scanln(&response)
+	return strings.ToLower(response) == "y"
+}
+
+// ---------- printDiffReport ----------
+func printDiffReport(report *core.DiffReport, fileTree *core.FileTree) {
+	for _, move := range report.DirRenamed {
+		fmt.Printf("Moved folder %s -> %s\n", move.From, move.To)
+	}
+	for _, move := range report.Renamed {
+		fmt.Printf("Renamed %s -> %s (%d%% similar)\n", move.From, move.To, move.Score)
+	}
+	for _, move := range report.Copied {
+		fmt.Printf("Copied %s -> %s (%d%% similar)\n", move.From, move.To, move.Score)
+	}
+	for _, p := range report.TypeChanged {
+		if node := fileTree.GetNode(p); node != nil {
+			fmt.Printf("%s is now a %s\n", p, node.Kind)
+		}
+	}
+	printRestored(report.Restored)
+	for _, move := range report.Rejected {
+		fmt.Printf("Not moving the description of %s to %s, describe %s again if it was a rename\n", move.From, move.To, move.To)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Tombstones(args []string) error {
+	flags := flag.NewFlagSet("tombstones", flag.ContinueOnError)
+	purge := flags.Bool("purge", false, "forget the tombstones of the given paths, or all of them when no path is given")
+	paths, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	if !*purge {
+		if len(paths) > 0 {
+			return fmt.Errorf("usage: gittier tombstones [--purge [path...]]")
+		}
+		if len(fileTree.Tombstones) == 0 {
+			fmt.Println("No tombstones")
+			return nil
+		}
+		for _, tombstone := range fileTree.Tombstones {
+			fmt.Printf("%s (deleted in %s): %s\n", tombstone.Path, shortHash(tombstone.DeletedIn), core.CommitTitle(tombstone.Description))
+		}
+		return nil
+	}
+
+	for i, p := range paths {
+		paths[i] = cleanTreePath(p)
+	}
+	count, err := fileTree.PurgeTombstones(paths)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		fmt.Println("No tombstones to purge")
+		return nil
+	}
+
+	if err := store.Save(fileTree, "Purge tombstones"); err != nil {
+		return fmt.Errorf("failed to save %s: %w", store.Name(), err)
+	}
+
+	fmt.Printf("Purged %d tombstone(s)\n", count)
+	return nil
+}
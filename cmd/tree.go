@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TyPeterson/Gittier/core"
+)
+
+func Tree(args []string) error {
+	flags := flag.NewFlagSet("tree", flag.ContinueOnError)
+	undescribed := flags.Bool("undescribed", false, "only show paths without a description")
+	depth := flags.Int("depth", 0, "maximum depth to show, 0 for no limit")
+	dirsOnly := flags.Bool("dirs-only", false, "leave out files")
+	withHistory := flags.Bool("with-history", false, "show the last commit on main touching each path")
+	noColor := flags.Bool("no-color", false, "disable colors")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	root := ""
+	if len(positional) > 0 {
+		root = cleanTreePath(positional[0])
+	}
+
+	store, err := core.OpenConfiguredStore()
+	if err != nil {
+		return err
+	}
+
+	fileTree, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	// show the descriptions as commit would publish them
+	if err := expandForDisplay(fileTree); err != nil {
+		return err
+	}
+
+	opts := core.RenderOptions{
+		MaxDepth:        *depth,
+		Root:            root,
+		DirsOnly:        *dirsOnly,
+		OnlyUndescribed: *undescribed,
+	}
+
+	var history map[string]core.CommitInfo
+	if *withHistory {
+		paths, err := core.CollectPaths(fileTree, opts)
+		if err != nil {
+			return err
+		}
+
+		history, err = core.LastCommits("main", paths)
+		if err != nil {
+			return err
+		}
+	}
+
+	output, err := core.RenderTerminalTree(fileTree, opts, !*noColor && isTerminal(os.Stdout), history)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+	return nil
+}
+
+// ---------- isTerminal ----------
+func isTerminal(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
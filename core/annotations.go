@@ -0,0 +1,269 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// AnnotationMarker starts an inline description, e.g. "// gittier: Entry point"
+const AnnotationMarker = "gittier:"
+
+// DirAnnotationFile holds the description of the folder it sits in
+const DirAnnotationFile = ".gittier"
+
+// annotationLines is how far from the top of a file an annotation may sit
+const annotationLines = 20
+
+// blobHead is how much of a file is read for annotations, header comments
+// and package comments
+const blobHead = 16 * 1024
+
+// commentSyntax is how a single line comment opens and, for block comments
+// written on one line, closes
+type commentSyntax struct {
+	open  string
+	close string
+}
+
+var (
+	slashComment = commentSyntax{open: "//"}
+	hashComment  = commentSyntax{open: "#"}
+	dashComment  = commentSyntax{open: "--"}
+	semiComment  = commentSyntax{open: ";"}
+	blockComment = commentSyntax{open: "/*", close: "*/"}
+	htmlComment  = commentSyntax{open: "<!--", close: "-->"}
+)
+
+// commentSyntaxes maps file extensions to the comments annotations are read from
+var commentSyntaxes = map[string][]commentSyntax{
+	".go": {slashComment}, ".c": {slashComment, blockComment}, ".h": {slashComment, blockComment},
+	".cc": {slashComment, blockComment}, ".cpp": {slashComment, blockComment}, ".hpp": {slashComment, blockComment},
+	".cs": {slashComment}, ".java": {slashComment}, ".kt": {slashComment}, ".kts": {slashComment},
+	".scala": {slashComment}, ".swift": {slashComment}, ".rs": {slashComment}, ".dart": {slashComment},
+	".js": {slashComment}, ".jsx": {slashComment}, ".mjs": {slashComment}, ".cjs": {slashComment},
+	".ts": {slashComment}, ".tsx": {slashComment}, ".php": {slashComment, hashComment},
+	".proto": {slashComment}, ".zig": {slashComment},
+	".py": {hashComment}, ".rb": {hashComment}, ".sh": {hashComment}, ".bash": {hashComment},
+	".zsh": {hashComment}, ".pl": {hashComment}, ".r": {hashComment}, ".jl": {hashComment},
+	".ex": {hashComment}, ".exs": {hashComment}, ".tf": {hashComment}, ".ps1": {hashComment},
+	".yaml": {hashComment}, ".yml": {hashComment}, ".toml": {hashComment}, ".mk": {hashComment},
+	".sql": {dashComment}, ".lua": {dashComment}, ".hs": {dashComment}, ".elm": {dashComment},
+	".clj": {semiComment}, ".lisp": {semiComment}, ".el": {semiComment},
+	".css": {blockComment}, ".scss": {slashComment, blockComment}, ".less": {slashComment, blockComment},
+	".html": {htmlComment}, ".htm": {htmlComment}, ".xml": {htmlComment}, ".md": {htmlComment},
+	".vue": {htmlComment}, ".svelte": {htmlComment},
+}
+
+// commentSyntaxNames covers files that are known by name rather than extension
+var commentSyntaxNames = map[string][]commentSyntax{
+	"Makefile":   {hashComment},
+	"Dockerfile": {hashComment},
+	"Rakefile":   {hashComment},
+	"Gemfile":    {hashComment},
+}
+
+// ---------- syntaxesFor ----------
+func syntaxesFor(p string) []commentSyntax {
+	name := path.Base(p)
+	if syntaxes, ok := commentSyntaxNames[name]; ok {
+		return syntaxes
+	}
+	return commentSyntaxes[strings.ToLower(path.Ext(name))]
+}
+
+// ---------- findAnnotation ----------
+// findAnnotation returns the first "gittier:" comment among the top lines
+// of content
+func findAnnotation(content []byte, syntaxes []commentSyntax) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for i := 0; i < annotationLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		for _, syntax := range syntaxes {
+			rest, ok := strings.CutPrefix(line, syntax.open)
+			if !ok {
+				continue
+			}
+			rest = strings.TrimSpace(rest)
+			if syntax.close != "" {
+				if rest, ok = strings.CutSuffix(rest, syntax.close); !ok {
+					continue
+				}
+			}
+			if description, ok := strings.CutPrefix(strings.TrimSpace(rest), AnnotationMarker); ok {
+				return strings.TrimSpace(description), true
+			}
+		}
+	}
+	return "", false
+}
+
+// ---------- AnnotationSource ----------
+// AnnotationSource is the file on main holding the annotation of node
+func AnnotationSource(node *PathNode) string {
+	if node.IsDir {
+		return joinPath(node.Path, DirAnnotationFile)
+	}
+	return node.Path
+}
+
+// ---------- ApplyAnnotations ----------
+// ApplyAnnotations reads the annotations of nodes from their blobs on main.
+// A file's own gittier: comment describes the file and a .gittier file
+// describes its folder, either one replacing the description in the tree.
+// Nodes whose annotation went away keep their description but are no longer
+// source-managed. It returns the paths described from an annotation
+func (ft *FileTree) ApplyAnnotations(nodes []*PathNode) ([]string, error) {
+	// node to describe -> blob holding its annotation
+	sources := make(map[*PathNode]*PathNode)
+	var targets []*PathNode
+	var blobs []string
+	for _, node := range nodes {
+		if node.IsDir {
+			// a folder that lost its .gittier file
+			if node.SourceManaged && !ft.HasNode(joinPath(node.Path, DirAnnotationFile)) {
+				node.SourceManaged = false
+			}
+			continue
+		}
+		if node.ObjectID == "" || (node.Kind != KindFile && node.Kind != KindExecutable) {
+			continue
+		}
+
+		target := node
+		if path.Base(node.Path) == DirAnnotationFile {
+			target = ft.GetNode(parentOf(node.Path))
+			if target == nil {
+				continue
+			}
+		} else if syntaxesFor(node.Path) == nil {
+			continue
+		}
+		if _, seen := sources[target]; !seen {
+			targets = append(targets, target)
+		}
+		sources[target] = node
+		blobs = append(blobs, node.ObjectID)
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	contents, err := readBlobs(blobs, blobHead)
+	if err != nil {
+		return nil, err
+	}
+
+	var annotated []string
+	for _, node := range targets {
+		source := sources[node]
+		content := contents[source.ObjectID]
+		var description string
+		var found bool
+		if source == node {
+			description, found = findAnnotation(content, syntaxesFor(node.Path))
+		} else {
+			description = strings.TrimSpace(string(content))
+			found = description != ""
+		}
+
+		if !found {
+			node.SourceManaged = false
+			continue
+		}
+		if node.SourceManaged && node.Description == description {
+			continue
+		}
+		node.SetDescription(description)
+		node.SourceManaged = true
+		annotated = append(annotated, node.Path)
+	}
+	return annotated, nil
+}
+
+// ---------- readBlobs ----------
+// readBlobs reads the first limit bytes of several blobs through a single
+// git cat-file --batch, which is all annotations, headers and package
+// comments need, so large files are never held in memory
+func readBlobs(ids []string, limit int) (map[string][]byte, error) {
+	contents := make(map[string][]byte)
+	err := scanBlobs(ids, func(id string, size int, content io.Reader) error {
+		head, err := io.ReadAll(io.LimitReader(content, int64(limit)))
+		contents[id] = head
+		return err
+	})
+	return contents, err
+}
+
+// ---------- scanBlobs ----------
+// scanBlobs streams several blobs through a single git cat-file --batch and
+// calls visit with each one found. Whatever visit leaves unread is skipped
+func scanBlobs(ids []string, visit func(id string, size int, content io.Reader) error) error {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to read blobs: %w", err)
+	}
+
+	// feed the ids while the output is read, or both sides fill their pipes
+	go func() {
+		defer stdin.Close()
+		io.WriteString(stdin, strings.Join(ids, "\n")+"\n")
+	}()
+
+	scanErr := func() error {
+		reader := bufio.NewReader(stdout)
+		for {
+			header, err := reader.ReadString('\n')
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			// "<id> <type> <size>", or "<id> missing"
+			fields := strings.Fields(header)
+			if len(fields) != 3 {
+				continue
+			}
+			size, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return fmt.Errorf("unexpected cat-file output: %s", header)
+			}
+
+			content := io.LimitReader(reader, int64(size))
+			if err := visit(fields[0], size, content); err != nil {
+				return err
+			}
+			if _, err := io.Copy(io.Discard, content); err != nil {
+				return err
+			}
+			// every object is followed by a newline
+			if _, err := reader.ReadByte(); err != nil {
+				return err
+			}
+		}
+	}()
+
+	if scanErr != nil {
+		// unblock git if it is still writing
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil && scanErr == nil {
+		return fmt.Errorf("failed to read blobs: %w", err)
+	}
+	return scanErr
+}
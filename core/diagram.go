@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// diagramNode is a rendered node together with the node it hangs from
+type diagramNode struct {
+	node   *PathNode
+	parent string
+}
+
+// ---------- diagramOrder ----------
+// diagramOrder flattens the selected nodes in the same post-order as
+// GetDfsOrder, so diagrams diff the same way filetree.yaml does
+func diagramOrder(nodes []*renderNode, parent string) []diagramNode {
+	var result []diagramNode
+	for _, rn := range nodes {
+		result = append(result, diagramOrder(rn.children, rn.node.Path)...)
+		result = append(result, diagramNode{node: rn.node, parent: parent})
+	}
+	return result
+}
+
+// ---------- diagramLabel ----------
+func diagramLabel(node *PathNode, opts RenderOptions) (string, string) {
+	return displayName(node), renderDescription(node, opts)
+}
+
+// ---------- RenderDot ----------
+func RenderDot(ft *FileTree, opts RenderOptions) (string, error) {
+	nodes, err := selectRenderRoot(ft, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph gittier {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	fmt.Fprintf(&b, "  %s [label=%s, shape=folder];\n", dotQuote(displayPath(opts.Root)), dotQuote(displayPath(opts.Root)))
+
+	order := diagramOrder(nodes, opts.Root)
+	for _, dn := range order {
+		name, description := diagramLabel(dn.node, opts)
+		label := name
+		if description != "" {
+			label += "\n" + description
+		}
+
+		shape := ""
+		if dn.node.IsDir {
+			shape = ", shape=folder"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", dotQuote(dn.node.Path), dotQuote(label), shape)
+	}
+	for _, dn := range order {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(displayPath(dn.parent)), dotQuote(dn.node.Path))
+	}
+
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// ---------- dotQuote ----------
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// ---------- RenderMermaid ----------
+func RenderMermaid(ft *FileTree, opts RenderOptions) (string, error) {
+	nodes, err := selectRenderRoot(ft, opts)
+	if err != nil {
+		return "", err
+	}
+
+	order := diagramOrder(nodes, opts.Root)
+
+	// ids derive from paths so adding a file does not renumber every node
+	ids := make(map[string]string)
+	used := make(map[string]bool)
+	assign := func(p string) {
+		id := mermaidID(p)
+		if used[id] {
+			hash := fnv.New32a()
+			hash.Write([]byte(p))
+			id = fmt.Sprintf("%s_%08x", id, hash.Sum32())
+		}
+		used[id] = true
+		ids[p] = id
+	}
+	assign(opts.Root)
+	for _, dn := range order {
+		assign(dn.node.Path)
+	}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	fmt.Fprintf(&b, "  %s[%s]\n", ids[opts.Root], mermaidQuote(displayPath(opts.Root)))
+
+	for _, dn := range order {
+		name, description := diagramLabel(dn.node, opts)
+		label := mermaidQuote(name)
+		if description != "" {
+			label = mermaidQuote(name + "\n" + description)
+		}
+		fmt.Fprintf(&b, "  %s[%s]\n", ids[dn.node.Path], label)
+	}
+	for _, dn := range order {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[dn.parent], ids[dn.node.Path])
+	}
+
+	return b.String(), nil
+}
+
+// ---------- mermaidID ----------
+func mermaidID(p string) string {
+	var b strings.Builder
+	b.WriteString("n_")
+	for _, r := range p {
+		if r < 128 && (r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// ---------- mermaidQuote ----------
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, "&", "#amp;")
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "<", "#lt;")
+	s = strings.ReplaceAll(s, ">", "#gt;")
+	s = strings.ReplaceAll(s, "\n", "<br/>")
+	return `"` + s + `"`
+}
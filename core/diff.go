@@ -0,0 +1,351 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultRenameThreshold is the similarity git itself requires before it
+// pairs a deleted path with an added one
+const DefaultRenameThreshold = 50
+
+// DiffEntry is a single change reported by git diff --name-status
+type DiffEntry struct {
+	// Status is the change letter, followed by a score for renames and copies (e.g. R087)
+	Status string
+	// Paths holds the changed path, or the source and destination of a rename or copy
+	Paths []string
+}
+
+// PathMove is a rename or copy from one path to another
+type PathMove struct {
+	From string
+	To   string
+	// Score is the similarity git reported, 100 for identical content
+	Score int
+}
+
+// SyncOptions tunes how ProcessGitDiff matches changed paths
+type SyncOptions struct {
+	// Confirm is asked before an ambiguous rename carries a description over,
+	// nil rejects every ambiguous rename
+	Confirm func(question string) bool
+	// Moves were recorded by gittier mv, already applied to the tree and
+	// contained in the diff
+	Moves []*Move
+}
+
+// DiffReport lists what ProcessGitDiff did beyond plain adds and deletes
+type DiffReport struct {
+	Renamed     []PathMove
+	DirRenamed  []PathMove
+	Copied      []PathMove
+	TypeChanged []string
+	// Rejected renames were treated as a delete followed by an add
+	Rejected []PathMove
+	// Restored holds paths that got the description of a tombstone back,
+	// From being the path the tombstone was left at
+	Restored []PathMove
+}
+
+// ---------- GetDiffOutput ----------
+// GetDiffOutput lists what changed on main since oldCommit. renameThreshold is
+// the similarity in percent a rename or copy needs, 0 uses git's default.
+// Copies are only looked for among the files that changed, unless
+// findCopiesHarder also offers every unchanged file as a source
+func GetDiffOutput(oldCommit string, renameThreshold int, findCopiesHarder bool) ([]DiffEntry, error) {
+	if renameThreshold == 0 {
+		renameThreshold = DefaultRenameThreshold
+	}
+	if renameThreshold < 1 || renameThreshold > 100 {
+		return nil, fmt.Errorf("rename threshold must be between 1 and 100, got %d", renameThreshold)
+	}
+
+	args := []string{"diff", "-z", "--name-status",
+		fmt.Sprintf("--find-renames=%d%%", renameThreshold),
+		fmt.Sprintf("--find-copies=%d%%", renameThreshold)}
+	// comparing every added file with every unchanged one grows with the
+	// size of the repository, and boilerplate would hand its description
+	// to unrelated files, so it is left to the repositories that want it
+	if findCopiesHarder {
+		args = append(args, "--find-copies-harder")
+	}
+	args = append(args, oldCommit, "refs/heads/main", "--")
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNameStatus(output)
+}
+
+// ---------- parseNameStatus ----------
+// parseNameStatus reads -z --name-status output, where every status and path
+// is terminated by a NUL and renames and copies carry two paths
+func parseNameStatus(output []byte) ([]DiffEntry, error) {
+	var entries []DiffEntry
+
+	// the last NUL terminates the last path rather than starting a token
+	tokens := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	for i := 0; i < len(tokens); i++ {
+		status := tokens[i]
+		if status == "" {
+			continue
+		}
+
+		pathCount := 1
+		if status[0] == 'R' || status[0] == 'C' {
+			pathCount = 2
+		}
+		if i+pathCount >= len(tokens) {
+			return nil, fmt.Errorf("truncated diff output after status %s", status)
+		}
+
+		entries = append(entries, DiffEntry{
+			Status: status,
+			Paths:  tokens[i+1 : i+1+pathCount],
+		})
+		i += pathCount
+	}
+
+	return entries, nil
+}
+
+// ---------- diffScore ----------
+func diffScore(status string) int {
+	score, err := strconv.Atoi(status[1:])
+	if err != nil {
+		return 100
+	}
+	return score
+}
+
+// ---------- ProcessGitDiff ----------
+// ProcessGitDiff replays the changes between fileTree and currentFileTree on
+// fileTree itself so descriptions follow their paths. Directories whose files
+// all moved the same way are moved as a unit, keeping the folder's own
+// description. currentFileTree only needs the folders the diff touches
+func ProcessGitDiff(fileTree *FileTree, diffOutput []DiffEntry, currentFileTree *FileTree, opts SyncOptions) (*DiffReport, error) {
+	report := &DiffReport{}
+
+	var added, deleted, typeChanged []string
+	var renames, copies []PathMove
+	// destinations of rejected renames, added without looking at tombstones
+	var rejected []string
+	for _, entry := range applyRecordedMoves(diffOutput, opts.Moves) {
+		switch entry.Status[0] {
+		case 'A':
+			added = append(added, entry.Paths[0])
+		case 'D':
+			deleted = append(deleted, entry.Paths[0])
+		case 'M':
+			// content changes keep their path and description
+		case 'T':
+			typeChanged = append(typeChanged, entry.Paths[0])
+		case 'R':
+			renames = append(renames, PathMove{From: entry.Paths[0], To: entry.Paths[1], Score: diffScore(entry.Status)})
+		case 'C':
+			copies = append(copies, PathMove{From: entry.Paths[0], To: entry.Paths[1], Score: diffScore(entry.Status)})
+		default:
+			return nil, fmt.Errorf("unexpected diff status %s for %s", entry.Status, entry.Paths[0])
+		}
+	}
+
+	// everything is decided before the tree is changed, so every lookup below
+	// sees the paths as they were. Whole folders first, the renames inside
+	// them need no further checks
+	report.DirRenamed = detectDirRenames(fileTree, currentFileTree, renames)
+	var moves []PathMove
+	moves = append(moves, report.DirRenamed...)
+	for _, rename := range renames {
+		if movedWithDir(report.DirRenamed, rename.From) {
+			continue
+		}
+
+		if node := fileTree.GetNode(rename.From); node != nil && IsDescribed(node) &&
+			ambiguousRename(fileTree, currentFileTree, rename, renames, copies, deleted, added) {
+			question := fmt.Sprintf("%s may have moved to %s (%d%% similar), but another path matches as well. Move its description?", rename.From, rename.To, rename.Score)
+			if opts.Confirm == nil || !opts.Confirm(question) {
+				report.Rejected = append(report.Rejected, rename)
+				deleted = append(deleted, rename.From)
+				rejected = append(rejected, rename.To)
+				continue
+			}
+		}
+
+		moves = append(moves, rename)
+		report.Renamed = append(report.Renamed, rename)
+	}
+
+	copied := make([]*PathNode, len(copies))
+	for i, cp := range copies {
+		copied[i] = NewPathNode(cp.To, false)
+		if source := fileTree.GetNode(cp.From); source != nil && IsDescribed(source) {
+			copied[i].Description = source.Description
+			copied[i].CopiedFrom = cp.From
+			copied[i].DescribedObject = source.DescribedObject
+		}
+	}
+
+	// detach every source before attaching anything, so swapped paths do not
+	// overwrite each other
+	detached := make([]*trieNode, len(moves))
+	for i, move := range moves {
+		detached[i] = fileTree.takeSubtree(move.From)
+	}
+	for _, oldPath := range deleted {
+		fileTree.bury(oldPath, currentFileTree.CommitHash)
+	}
+	for i, move := range moves {
+		fileTree.putSubtree(move.To, detached[i])
+	}
+
+	for _, newPath := range added {
+		newNode := NewPathNode(newPath, false)
+		objectID := ""
+		if current := currentFileTree.GetNode(newPath); current != nil {
+			objectID = current.ObjectID
+		}
+		if tombstone := fileTree.restore(newNode, objectID); tombstone != nil {
+			report.Restored = append(report.Restored, PathMove{From: tombstone.Path, To: newPath, Score: 100})
+		}
+		fileTree.AddNode(newNode)
+	}
+	for _, newPath := range rejected {
+		fileTree.AddNode(NewPathNode(newPath, false))
+	}
+
+	for i, cp := range copies {
+		fileTree.AddNode(copied[i])
+		report.Copied = append(report.Copied, cp)
+	}
+
+	// type and content changes keep the description, the object id is moved
+	// on by ApplyChangedTree together with everything else that changed
+	report.TypeChanged = typeChanged
+
+	return report, nil
+}
+
+// ---------- movedWithDir ----------
+func movedWithDir(dirMoves []PathMove, p string) bool {
+	for _, move := range dirMoves {
+		if strings.HasPrefix(p, move.From+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------- renamedDirs ----------
+// renamedDirs strips the path segments from and to have in common at the end
+// and returns the folders left over, e.g. a/b/c.go and x/b/c.go give a and x
+func renamedDirs(from, to string) (string, string) {
+	fromParts := strings.Split(from, "/")
+	toParts := strings.Split(to, "/")
+
+	common := 0
+	for len(fromParts)-common > 1 && len(toParts)-common > 1 &&
+		fromParts[len(fromParts)-1-common] == toParts[len(toParts)-1-common] {
+		common++
+	}
+	if common == 0 {
+		return "", ""
+	}
+
+	return strings.Join(fromParts[:len(fromParts)-common], "/"), strings.Join(toParts[:len(toParts)-common], "/")
+}
+
+// ---------- detectDirRenames ----------
+// detectDirRenames finds folders that disappeared while every file in them
+// reappeared at the same place below a new folder
+func detectDirRenames(oldFileTree, currentFileTree *FileTree, renames []PathMove) []PathMove {
+	renamedTo := make(map[string]string)
+	candidates := make(map[PathMove]bool)
+	for _, rename := range renames {
+		renamedTo[rename.From] = rename.To
+		if fromDir, toDir := renamedDirs(rename.From, rename.To); fromDir != "" {
+			candidates[PathMove{From: fromDir, To: toDir, Score: 100}] = true
+		}
+	}
+
+	var found []PathMove
+	for candidate := range candidates {
+		if dirRenameHolds(oldFileTree, currentFileTree, renamedTo, candidate) {
+			found = append(found, candidate)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].From < found[j].From
+	})
+
+	// a folder moving with its parent is already covered by the parent
+	var result []PathMove
+	for _, move := range found {
+		if !movedWithDir(result, move.From) {
+			result = append(result, move)
+		}
+	}
+	return result
+}
+
+// ---------- dirRenameHolds ----------
+func dirRenameHolds(oldFileTree, currentFileTree *FileTree, renamedTo map[string]string, move PathMove) bool {
+	oldDir := oldFileTree.GetNode(move.From)
+	newDir := currentFileTree.GetNode(move.To)
+	if oldDir == nil || !oldDir.IsDir || newDir == nil || !newDir.IsDir {
+		return false
+	}
+	if currentFileTree.HasNode(move.From) || oldFileTree.HasNode(move.To) {
+		return false
+	}
+
+	files := 0
+	for _, node := range oldFileTree.getSubtree(move.From) {
+		if node.IsDir {
+			continue
+		}
+		if renamedTo[node.Path] != move.To+strings.TrimPrefix(node.Path, move.From) {
+			return false
+		}
+		files++
+	}
+	return files > 0
+}
+
+// ---------- ambiguousRename ----------
+// ambiguousRename reports whether git had to pick between several equally
+// good partners for a rename: identical content leaving or arriving at more
+// than one path, or the same source also showing up as a copy
+func ambiguousRename(oldFileTree, currentFileTree *FileTree, rename PathMove, renames, copies []PathMove, deleted, added []string) bool {
+	for _, cp := range copies {
+		if cp.From == rename.From {
+			return true
+		}
+	}
+
+	gone := append([]string{}, deleted...)
+	arrived := append([]string{}, added...)
+	for _, other := range renames {
+		gone = append(gone, other.From)
+		arrived = append(arrived, other.To)
+	}
+
+	if source := oldFileTree.GetNode(rename.From); source != nil && source.ObjectID != "" {
+		for _, p := range gone {
+			if node := oldFileTree.GetNode(p); p != rename.From && node != nil && node.ObjectID == source.ObjectID {
+				return true
+			}
+		}
+	}
+	if target := currentFileTree.GetNode(rename.To); target != nil && target.ObjectID != "" {
+		for _, p := range arrived {
+			if node := currentFileTree.GetNode(p); p != rename.To && node != nil && node.ObjectID == target.ObjectID {
+				return true
+			}
+		}
+	}
+	return false
+}
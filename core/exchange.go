@@ -0,0 +1,224 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportFormats lists the formats understood by ExportFileTree and ParseRecords
+var ExportFormats = []string{"json", "csv", "toml"}
+
+// Record is a single path and its description in an exported file
+type Record struct {
+	Path        string `json:"path" toml:"path"`
+	Description string `json:"description" toml:"description"`
+	IsDir       bool   `json:"is_dir" toml:"is_dir"`
+}
+
+// exportDocument is the JSON and TOML layout of an export
+type exportDocument struct {
+	Version    int      `json:"version" toml:"version"`
+	CommitHash string   `json:"commit_hash" toml:"commit_hash"`
+	Nodes      []Record `json:"nodes" toml:"nodes"`
+}
+
+// ImportReport summarizes what an import changed
+type ImportReport struct {
+	Updated   []string
+	Unchanged []string
+	// Skipped paths were left alone in merge mode, either because they already
+	// had a description or because the import had none to offer
+	Skipped []string
+	// Unknown paths were in the import but not in the tree
+	Unknown []string
+	// Missing paths are in the tree but were not in the import
+	Missing []string
+}
+
+// ---------- FormatFromFilename ----------
+func FormatFromFilename(filename string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+}
+
+// ---------- IsDescribed ----------
+// IsDescribed reports whether a node has a description other than the placeholder
+func IsDescribed(node *PathNode) bool {
+	return hasDescription(node.Description)
+}
+
+// ---------- hasDescription ----------
+func hasDescription(description string) bool {
+	return strings.TrimSpace(description) != "" && description != NoDescription
+}
+
+// ---------- ExportFileTree ----------
+func ExportFileTree(ft *FileTree, format string, w io.Writer) error {
+	doc := exportDocument{
+		Version:    SchemaVersion,
+		CommitHash: ft.CommitHash,
+	}
+
+	nodes := GetDfsOrder(ft)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Path < nodes[j].Path
+	})
+
+	for _, node := range nodes {
+		doc.Nodes = append(doc.Nodes, Record{
+			Path:        node.Path,
+			Description: node.Description,
+			IsDir:       node.IsDir,
+		})
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(doc)
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"path", "description", "is_dir"}); err != nil {
+			return err
+		}
+		for _, record := range doc.Nodes {
+			if err := writer.Write([]string{record.Path, record.Description, strconv.FormatBool(record.IsDir)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case "toml":
+		return writeToml(w, &doc)
+	default:
+		return fmt.Errorf("unsupported format %q (expected %s)", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+// ---------- ParseRecords ----------
+func ParseRecords(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case "json":
+		var doc exportDocument
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("error parsing JSON: %w", err)
+		}
+		return doc.Nodes, nil
+	case "csv":
+		return parseCsvRecords(r)
+	case "toml":
+		doc, err := readToml(r)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing TOML: %w", err)
+		}
+		return doc.Nodes, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q (expected %s)", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+// ---------- parseCsvRecords ----------
+func parseCsvRecords(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		// spreadsheets like to add a byte order mark to the first cell
+		name = strings.TrimPrefix(strings.TrimSpace(strings.ToLower(name)), "\ufeff")
+		columns[name] = i
+	}
+	pathCol, hasPath := columns["path"]
+	descCol, hasDesc := columns["description"]
+	dirCol, hasDir := columns["is_dir"]
+	if !hasPath || !hasDesc {
+		return nil, errors.New("CSV header must contain 'path' and 'description' columns")
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %w", err)
+		}
+		if len(row) <= pathCol || len(row) <= descCol {
+			return nil, fmt.Errorf("CSV line %d: missing columns", line)
+		}
+
+		record := Record{Path: row[pathCol], Description: row[descCol]}
+		if hasDir && len(row) > dirCol && strings.TrimSpace(row[dirCol]) != "" {
+			record.IsDir, err = strconv.ParseBool(strings.TrimSpace(row[dirCol]))
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid is_dir value %q", line, row[dirCol])
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// ---------- ImportRecords ----------
+// ImportRecords applies the descriptions in records to ft. In merge mode only
+// nodes without a description are filled in
+func ImportRecords(ft *FileTree, records []Record, merge bool) (*ImportReport, error) {
+	report := &ImportReport{}
+	seen := make(map[string]bool)
+	var invalid []error
+
+	for _, record := range records {
+		path := filepath.ToSlash(filepath.Clean(record.Path))
+		if seen[path] {
+			return nil, fmt.Errorf("path listed more than once: %s", path)
+		}
+		seen[path] = true
+
+		node := ft.GetNode(path)
+		switch {
+		case node == nil:
+			report.Unknown = append(report.Unknown, path)
+		case node.Description == record.Description:
+			report.Unchanged = append(report.Unchanged, path)
+		case merge && (IsDescribed(node) || !hasDescription(record.Description)):
+			report.Skipped = append(report.Skipped, path)
+		default:
+			if err := CheckPlaceholders(record.Description, node); err != nil {
+				invalid = append(invalid, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			node.SetDescription(record.Description)
+			report.Updated = append(report.Updated, path)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid descriptions: %w", errors.Join(invalid...))
+	}
+
+	for _, node := range GetDfsOrder(ft) {
+		if !seen[node.Path] {
+			report.Missing = append(report.Missing, node.Path)
+		}
+	}
+
+	sort.Strings(report.Unknown)
+	sort.Strings(report.Missing)
+	return report, nil
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -18,6 +19,16 @@ func IsGitRepo() bool {
 	return err == nil
 }
 
+// ---------- GetRepoName ----------
+func GetRepoName() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find repository root: %w", err)
+	}
+	return filepath.Base(strings.TrimSpace(string(output))), nil
+}
+
 // ---------- BranchExists ----------
 func BranchExists(branch string) bool {
 	cmd := exec.Command("git", "branch", "--list", branch)
@@ -86,121 +97,220 @@ func GetCommitHash(branch string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// ---------- GetFileTreeFromLsTree ----------
-func GetFileTreeFromBranch(branch string) (*FileTree, error) {
-	cmd := exec.Command("git", "ls-tree", "-r", "-t", "--name-only", branch)
+// LsTreeEntry is a single line of git ls-tree output
+type LsTreeEntry struct {
+	Mode     string
+	Type     string
+	ObjectID string
+	Path     string
+}
+
+// ---------- ListTree ----------
+func ListTree(branch string) ([]LsTreeEntry, error) {
+	return lsTree("-r", "-t", "--full-tree", branch)
+}
+
+// ---------- lsTree ----------
+func lsTree(args ...string) ([]LsTreeEntry, error) {
+	cmd := exec.Command("git", append([]string{"ls-tree", "-z"}, args...)...)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ls-tree output: %w", err)
 	}
 
-	commitHash, err := GetCommitHash(branch)
-	if err != nil {
-		return nil, err
-	}
-
-	fileTree := NewFileTree(commitHash)
-
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-
+	// -z keeps paths verbatim instead of C-quoting unusual characters
+	var entries []LsTreeEntry
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	scanner.Split(splitNul)
 	for scanner.Scan() {
-		path := scanner.Text()
-
-		// check if the path is a directory
-		isDir := false
-		fullPath := filepath.Join(".", path) // Prepend current directory
-		fileInfo, err := os.Stat(fullPath)
-		if err == nil {
-			isDir = fileInfo.IsDir()
-		} else if !os.IsNotExist(err) {
-			// If there's an error other than "not exists", log it but continue
-			fmt.Printf("Warning: Error checking %s: %v\n", fullPath, err)
+		entry, err := parseLsTreeLine(scanner.Text())
+		if err != nil {
+			return nil, err
 		}
-
-		// ensure the parent directories are added
-		dirs := strings.Split(filepath.Dir(path), string(filepath.Separator))
-		currentPath := ""
-		for _, dir := range dirs {
-			currentPath = filepath.Join(currentPath, dir)
-			if currentPath != "." && !fileTree.HasNode(currentPath) {
-				fileTree.AddNode(NewPathNode(currentPath, true))
-			}
-		}
-
-		// add the file or directory
-		fileTree.AddNode(NewPathNode(path, isDir))
+		entries = append(entries, entry)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error scanning ls-tree output: %w", err)
 	}
+	return entries, nil
+}
 
-	return fileTree, nil
+// ---------- parseLsTreeLine ----------
+// parseLsTreeLine splits "<mode> SP <type> SP <object> TAB <path>"
+func parseLsTreeLine(line string) (LsTreeEntry, error) {
+	info, path, found := strings.Cut(line, "\t")
+	fields := strings.Fields(info)
+	if !found || len(fields) != 3 {
+		return LsTreeEntry{}, fmt.Errorf("unexpected ls-tree line: %q", line)
+	}
+
+	return LsTreeEntry{
+		Mode:     fields[0],
+		Type:     fields[1],
+		ObjectID: fields[2],
+		Path:     path,
+	}, nil
 }
 
-// ---------- GetDiffOutput ----------
-func GetDiffOutput(oldCommit string) ([]string, error) {
-	cmd := exec.Command("git", "diff", "--name-status", oldCommit, "refs/heads/main")
-	output, err := cmd.Output()
+// ---------- GetFileTreeFromLsTree ----------
+func GetFileTreeFromBranch(branch string) (*FileTree, error) {
+	entries, err := ListTree(branch)
 	if err != nil {
 		return nil, err
 	}
-	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
-}
 
-// ---------- ProcessGitDiff ----------
-func ProcessGitDiff(oldFileTree *FileTree, diffOutput []string) (*FileTree, error) {
-	updatedFileTree := oldFileTree.Clone()
+	commitHash, err := GetCommitHash(branch)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, line := range diffOutput {
-		parts := strings.Split(line, "\t")
-		if len(parts) < 2 {
-			continue
-		}
+	fileTree := NewFileTree(commitHash)
 
-		changeType := parts[0]
-		switch changeType[0] {
-		case 'A':
-			newPath := parts[1]
-			newNode := NewPathNode(newPath, false)
-			updatedFileTree.AddNode(newNode)
-		case 'D':
-			oldPath := parts[1]
-			updatedFileTree.DeleteNode(oldPath)
-		case 'R':
-			if len(parts) < 3 {
-				continue
-			}
-			oldPath, newPath := parts[1], parts[2]
-			updatedFileTree.UpdateNodePath(oldPath, newPath)
-		}
+	// -t lists every tree before its contents, so parents always come first
+	for _, entry := range entries {
+		fileTree.AddNode(NewPathNodeFromEntry(entry))
 	}
 
-	return updatedFileTree, nil
+	return fileTree, nil
 }
 
 // ---------- SyncFileTree ----------
 func SyncFileTree(updatedFileTree, currentFileTree *FileTree) *FileTree {
 	syncedFileTree := NewFileTree(currentFileTree.CommitHash)
+	syncedFileTree.CopyLayout(updatedFileTree)
 
 	dfsOrder := GetDfsOrder(currentFileTree)
 	for _, node := range dfsOrder {
-		if updatedNode, exists := updatedFileTree.Nodes[node.Path]; exists {
+		if updatedNode := updatedFileTree.GetNode(node.Path); updatedNode != nil {
+			// keep the description but take kind and object id from main
+			updatedNode.SetObject(node)
 			syncedFileTree.AddNode(updatedNode)
 		} else {
 			newNode := NewPathNode(node.Path, node.IsDir)
+			newNode.SetObject(node)
 			syncedFileTree.AddNode(newNode)
 		}
 	}
 	return syncedFileTree
 }
 
+// CommitInfo is the summary of a commit shown next to a path
+type CommitInfo struct {
+	Hash      string
+	ShortHash string
+	Date      string
+	Author    string
+	Subject   string
+}
+
+// ---------- LastCommits ----------
+// LastCommits finds the most recent commit on branch touching each of paths,
+// where a directory is touched by any change below it. The history is read
+// once, newest first, and stops as soon as every path has been seen
+func LastCommits(branch string, paths []string) (map[string]CommitInfo, error) {
+	result := make(map[string]CommitInfo)
+	wanted := make(map[string]bool)
+	for _, path := range paths {
+		wanted[path] = true
+	}
+
+	cmd := exec.Command("git", "log", "-z", "--name-only", "--format=%x01%H%x09%h%x09%as%x09%an%x09%s", branch, "--")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", branch, err)
+	}
+	defer cmd.Wait()
+	defer stdout.Close()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	scanner.Split(splitNul)
+
+	var current CommitInfo
+	for scanner.Scan() && len(result) < len(wanted) {
+		token := scanner.Text()
+		if strings.HasPrefix(token, "\x01") {
+			fields := strings.SplitN(token[1:], "\t", 5)
+			if len(fields) == 5 {
+				current = CommitInfo{Hash: fields[0], ShortHash: fields[1], Date: fields[2], Author: fields[3], Subject: fields[4]}
+			}
+			continue
+		}
+
+		// the first path of each commit follows the header's newline
+		path := strings.TrimPrefix(token, "\n")
+		for path != "" {
+			if wanted[path] {
+				if _, seen := result[path]; !seen {
+					result[path] = current
+				}
+			}
+			path = parentOf(path)
+		}
+	}
+
+	return result, nil
+}
+
+// ---------- splitNul ----------
+// splitNul is a bufio.SplitFunc for the NUL separated output of -z
+func splitNul(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
 // ---------- gitRename ----------
 func gitRename(oldPath, newPath string) error {
-	cmd := exec.Command("git", "mv", oldPath, newPath)
+	cmd := exec.Command("git", "mv", "--", oldPath, newPath)
 	return cmd.Run()
 }
 
+// ---------- GitMove ----------
+// GitMove runs git mv for paths relative to the repository root, whatever
+// directory gittier was started in. force lets it overwrite oldPath's target
+func GitMove(oldPath, newPath string, force bool) error {
+	root, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return fmt.Errorf("failed to find repository root: %w", err)
+	}
+
+	args := []string{"mv"}
+	if force {
+		args = append(args, "-f")
+	}
+	cmd := exec.Command("git", append(args, "--", oldPath, newPath)...)
+	cmd.Dir = strings.TrimSpace(string(root))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git mv failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ---------- CommitNodeDescription ----------
+// CommitNodeDescription makes the node's description the latest commit
+// touching its path, using a strategy that suits the kind of node
+func CommitNodeDescription(node *PathNode) error {
+	switch node.Kind {
+	case KindDir:
+		return CommitFolderDescription(node)
+	case KindSubmodule:
+		return CommitSubmoduleDescription(node)
+	default:
+		// files, executables and symlinks can all be renamed with git mv
+		return CommitFileDescription(node)
+	}
+}
+
 // ---------- CommitFolder ----------
 func CommitFolderDescription(node *PathNode) error {
 	tempFile := filepath.Join(node.Path, ".temp_commit_file")
@@ -228,20 +338,48 @@ func CommitFileDescription(node *PathNode) error {
 		return err
 	}
 
-	if err := Commit(node.Description); err != nil {
+	if err := Commit(fmt.Sprintf("%s temp commit", node.Path)); err != nil {
 		return err
 	}
 
+	// renaming back is the commit that carries the description
 	if err := gitRename(tempFileName, node.Path); err != nil {
 		return err
 	}
 
-	return nil
+	return Commit(node.Description)
+}
+
+// ---------- CommitSubmodule ----------
+// CommitSubmoduleDescription removes and restores the gitlink in the index,
+// since a submodule cannot be renamed like a file without touching .gitmodules
+func CommitSubmoduleDescription(node *PathNode) error {
+	commit := node.ObjectID
+	if commit == "" {
+		return fmt.Errorf("no commit recorded for submodule %s", node.Path)
+	}
+
+	removeCmd := exec.Command("git", "update-index", "--force-remove", "--", node.Path)
+	if err := removeCmd.Run(); err != nil {
+		return fmt.Errorf("failed to unstage submodule %s: %w", node.Path, err)
+	}
+
+	if err := Commit(fmt.Sprintf("%s temp commit", node.Path)); err != nil {
+		return err
+	}
+
+	addCmd := exec.Command("git", "update-index", "--add", "--cacheinfo", fmt.Sprintf("160000,%s,%s", commit, node.Path))
+	if err := addCmd.Run(); err != nil {
+		return fmt.Errorf("failed to restore submodule %s: %w", node.Path, err)
+	}
+
+	return Commit(node.Description)
 }
 
 // ---------- Stage ----------
 func Stage(path string) error {
-	cmd := exec.Command("git", "add", path)
+	// literal pathspecs keep names like "glob[1].txt" from matching other files
+	cmd := exec.Command("git", "--literal-pathspecs", "add", "--", path)
 	return cmd.Run()
 }
 
@@ -270,11 +408,17 @@ func StageAndCommit(path, message string) error {
 	return nil
 }
 
+// ---------- IsTracked ----------
+func IsTracked(path string) bool {
+	cmd := exec.Command("git", "--literal-pathspecs", "ls-files", "--error-unmatch", "--", path)
+	return cmd.Run() == nil
+}
+
 // ---------- StageAndCommitBulk ----------
 // func StageAndCommitBulk(path, message string) error
 
-// ---------- mergeBranch ----------
-func mergeBranch(targetBranch, sourceBranch string) error {
+// ---------- MergeBranch ----------
+func MergeBranch(targetBranch, sourceBranch string) error {
 	originalBranch, err := GetCurrentBranch()
 	if err != nil {
 		return fmt.Errorf("failed to get current branch: %w", err)
@@ -299,12 +443,38 @@ func mergeBranch(targetBranch, sourceBranch string) error {
 		}()
 	}
 
-	cmd := exec.Command("git", "merge", sourceBranch)
+	cmd := exec.Command("git", "merge", "--no-edit", sourceBranch)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		// READMEs gittier rewrites anyway are the only conflicts it settles
+		resolved, resolveErr := resolveReadmeConflicts()
+		if resolveErr == nil && resolved {
+			return nil
+		}
+
+		// leave the branch as it was rather than half merged
+		exec.Command("git", "merge", "--abort").Run()
+		if resolveErr != nil {
+			return fmt.Errorf("failed to merge branch %s into branch %s: %w", sourceBranch, targetBranch, resolveErr)
+		}
 		return fmt.Errorf("failed to merge branch %s into branch %s: %w\n%s", sourceBranch, targetBranch, err, string(output))
 	}
-
-	fmt.Printf("Merge successful. Output: %s\n", string(output))
 	return nil
 }
+
+// ---------- unmergedPaths ----------
+func unmergedPaths() ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "-z", "--diff-filter=U")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicts: %w", err)
+	}
+
+	var paths []string
+	for _, p := range strings.Split(string(output), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// unusualNames can not all be checked out on every file system, so they
+// are only ever created inside a temporary repository
+var unusualNames = []string{
+	"line\nbreak.txt",
+	"tab\there.txt",
+	`double"quote.txt`,
+	`back\slash.txt`,
+	"trailing space .txt",
+}
+
+// ---------- newTestRepo ----------
+// newTestRepo creates a repository with files on main and makes it the
+// working directory for the rest of the test
+func newTestRepo(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	git(t, "init", "-q", "-b", "main")
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "initial")
+}
+
+// ---------- git ----------
+func git(t *testing.T, args ...string) string {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ---------- fixtureNames ----------
+// fixtureNames lists the paths below cmd/nestedFolder/pathFixtures together
+// with unusualNames, relative to a "fixtures" folder
+func fixtureNames(t *testing.T) []string {
+	t.Helper()
+
+	root := filepath.Join("..", "cmd", "nestedFolder", "pathFixtures")
+	var names []string
+	err := filepath.WalkDir(root, func(p string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		names = append(names, "fixtures/"+filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Fatalf("no fixtures found in %s", root)
+	}
+
+	for _, name := range unusualNames {
+		names = append(names, "fixtures/"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestLsTreeKeepsUnusualPathsVerbatim(t *testing.T) {
+	names := fixtureNames(t)
+	files := make(map[string]string)
+	for _, name := range names {
+		files[name] = "content of " + name
+	}
+	newTestRepo(t, files)
+
+	entries, err := ListTree("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		if entry.Type == "blob" {
+			got = append(got, entry.Path)
+		}
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("ListTree paths =\n%q\nwant\n%q", got, names)
+	}
+}
+
+func TestNameStatusAndRenameKeepUnusualPathsVerbatim(t *testing.T) {
+	names := fixtureNames(t)
+	files := make(map[string]string)
+	for _, name := range names {
+		files[name] = "content of " + name
+	}
+	newTestRepo(t, files)
+	oldCommit := git(t, "rev-parse", "HEAD")
+
+	// rename every fixture into a sibling folder and delete nothing else
+	var want []DiffEntry
+	for _, name := range names {
+		renamed := "renamed/" + strings.TrimPrefix(name, "fixtures/")
+		if err := os.MkdirAll(filepath.Dir(renamed), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := gitRename(name, renamed); err != nil {
+			t.Fatalf("gitRename(%q, %q): %v", name, renamed, err)
+		}
+		want = append(want, DiffEntry{Status: "R100", Paths: []string{name, renamed}})
+	}
+	git(t, "commit", "-q", "-m", "rename fixtures")
+
+	got, err := GetDiffOutput(oldCommit, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Paths[0] < got[j].Paths[0] })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDiffOutput =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestParseNameStatus(t *testing.T) {
+	output := "M\x00line\nbreak.txt\x00R087\x00old \"name\".txt\x00new\tname.txt\x00C100\x00a.go\x00café.go\x00D\x00-dash\x00"
+	want := []DiffEntry{
+		{Status: "M", Paths: []string{"line\nbreak.txt"}},
+		{Status: "R087", Paths: []string{`old "name".txt`, "new\tname.txt"}},
+		{Status: "C100", Paths: []string{"a.go", "café.go"}},
+		{Status: "D", Paths: []string{"-dash"}},
+	}
+
+	got, err := parseNameStatus([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNameStatus = %q, want %q", got, want)
+	}
+
+	if _, err := parseNameStatus([]byte("R100\x00only-one-path\x00")); err == nil {
+		t.Error("parseNameStatus accepted a rename with a single path")
+	}
+}
+
+func TestGetDiffOutputCopiesOfUnchangedFiles(t *testing.T) {
+	newTestRepo(t, map[string]string{"LICENSE": strings.Repeat("boilerplate line\n", 20)})
+	oldCommit := git(t, "rev-parse", "HEAD")
+
+	if err := os.WriteFile("COPYING", []byte(strings.Repeat("boilerplate line\n", 20)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "copy the license")
+
+	tests := []struct {
+		findCopiesHarder bool
+		want             []DiffEntry
+	}{
+		{false, []DiffEntry{{Status: "A", Paths: []string{"COPYING"}}}},
+		{true, []DiffEntry{{Status: "C100", Paths: []string{"LICENSE", "COPYING"}}}},
+	}
+	for _, test := range tests {
+		got, err := GetDiffOutput(oldCommit, 0, test.findCopiesHarder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GetDiffOutput(findCopiesHarder=%v) = %q, want %q", test.findCopiesHarder, got, test.want)
+		}
+	}
+}
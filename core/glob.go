@@ -0,0 +1,63 @@
+package core
+
+import (
+	"path"
+	"strings"
+)
+
+// ---------- matchGlob ----------
+// matchGlob reports whether p matches pattern. Segments are matched with
+// path.Match, "**" stands for any number of segments (at least one at the
+// end of the pattern), a leading "/" anchors
+// the pattern at the repository root and a pattern without any other "/"
+// matches the name at any depth, like in .gitignore
+func matchGlob(pattern, p string) bool {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), splitPath(p))
+}
+
+// ---------- matchSegments ----------
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// a trailing ** matches what is inside a folder, not the folder
+			first := 0
+			if len(pattern) == 1 {
+				first = 1
+			}
+			// try every number of segments the ** could stand for
+			for skip := first; skip <= len(segments); skip++ {
+				if matchSegments(pattern[1:], segments[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// ---------- validGlob ----------
+func validGlob(pattern string) bool {
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if segment == "" {
+			return false
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
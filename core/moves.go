@@ -0,0 +1,182 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ---------- MoveNode ----------
+// MoveNode moves from and everything below it to to, like UpdateNodePath,
+// and records the move so the next sync knows where the paths went
+func (ft *FileTree) MoveNode(from, to string) error {
+	if err := ft.UpdateNodePath(from, to); err != nil {
+		return err
+	}
+	ft.recordMove(from, to)
+	return nil
+}
+
+// ---------- recordMove ----------
+// recordMove appends a move, folding it into the previous one when that
+// ended where this one starts
+func (ft *FileTree) recordMove(from, to string) {
+	if last := len(ft.Moves) - 1; last >= 0 && ft.Moves[last].To == from {
+		if ft.Moves[last].From == to {
+			// moved back where it came from
+			ft.Moves = ft.Moves[:last]
+		} else {
+			ft.Moves[last].To = to
+		}
+		return
+	}
+	ft.Moves = append(ft.Moves, &Move{From: from, To: to})
+}
+
+// ---------- DescribedPaths ----------
+// DescribedPaths lists p and the paths below it that have a description
+func (ft *FileTree) DescribedPaths(p string) []string {
+	var described []string
+	for _, node := range ft.getSubtree(p) {
+		if IsDescribed(node) {
+			described = append(described, node.Path)
+		}
+	}
+	return described
+}
+
+// ---------- SplitMoves ----------
+// SplitMoves separates the recorded moves that branch already contains from
+// the ones that were not committed or merged yet. A move landed when branch
+// has its destination and either lost its source or got the destination
+// since the last sync, in case a new file took the old place again
+func (ft *FileTree) SplitMoves(branch string) (landed, waiting []*Move) {
+	for _, move := range ft.Moves {
+		arrived := pathOnBranch(branch, move.To) &&
+			(!pathOnBranch(branch, move.From) || !pathOnBranch(ft.CommitHash, move.To))
+		if arrived {
+			landed = append(landed, move)
+		} else {
+			waiting = append(waiting, move)
+		}
+	}
+	return landed, waiting
+}
+
+// ---------- RevertMoves ----------
+// RevertMoves puts the paths of moves that did not land yet back where branch
+// still has them, latest move first. Moves that can not be put back are left
+// for ReplayMoves to drop
+func (ft *FileTree) RevertMoves(moves []*Move) error {
+	for i := len(moves) - 1; i >= 0; i-- {
+		if !ft.HasNode(moves[i].To) || ft.HasNode(moves[i].From) {
+			continue
+		}
+		if err := ft.UpdateNodePath(moves[i].To, moves[i].From); err != nil {
+			return fmt.Errorf("failed to put %s back at %s: %w", moves[i].To, moves[i].From, err)
+		}
+	}
+	return nil
+}
+
+// ---------- ReplayMoves ----------
+// ReplayMoves moves and records again what RevertMoves put back, returning
+// the moves whose source is gone or whose destination is taken by now
+func (ft *FileTree) ReplayMoves(moves []*Move) []*Move {
+	var dropped []*Move
+	for _, move := range moves {
+		if !ft.HasNode(move.From) || ft.HasNode(move.To) || ft.MoveNode(move.From, move.To) != nil {
+			dropped = append(dropped, move)
+		}
+	}
+	return dropped
+}
+
+// ---------- pathOnBranch ----------
+func pathOnBranch(branch, p string) bool {
+	cmd := exec.Command("git", "cat-file", "-e", branch+":"+p)
+	return cmd.Run() == nil
+}
+
+// ---------- applyRecordedMoves ----------
+// applyRecordedMoves rewrites a diff for moves already applied to the tree.
+// A path that arrived where a recorded move put it needs nothing, whatever
+// git paired it with, and a moved path that is gone is deleted at its new
+// place. Renames that git found anywhere else are kept
+func applyRecordedMoves(diffOutput []DiffEntry, moves []*Move) []DiffEntry {
+	if len(moves) == 0 {
+		return diffOutput
+	}
+
+	movedPath := func(p string) (string, bool) {
+		moved := false
+		for _, move := range moves {
+			if p == move.From || strings.HasPrefix(p, move.From+"/") {
+				p = move.To + strings.TrimPrefix(p, move.From)
+				moved = true
+			}
+		}
+		return p, moved
+	}
+
+	arrived := make(map[string]bool)
+	for _, entry := range diffOutput {
+		switch entry.Status[0] {
+		case 'A':
+			arrived[entry.Paths[0]] = true
+		case 'R', 'C':
+			arrived[entry.Paths[1]] = true
+		}
+	}
+	// paths that already sit where main has them
+	accounted := make(map[string]bool)
+	for _, entry := range diffOutput {
+		if entry.Status[0] != 'D' && entry.Status[0] != 'R' {
+			continue
+		}
+		if p, moved := movedPath(entry.Paths[0]); moved && arrived[p] {
+			accounted[p] = true
+		}
+	}
+
+	var result []DiffEntry
+	for _, entry := range diffOutput {
+		switch entry.Status[0] {
+		case 'A':
+			if !accounted[entry.Paths[0]] {
+				result = append(result, entry)
+			}
+		case 'D':
+			if p, moved := movedPath(entry.Paths[0]); !moved || !accounted[p] {
+				result = append(result, DiffEntry{Status: entry.Status, Paths: []string{p}})
+			}
+		case 'M', 'T':
+			p, _ := movedPath(entry.Paths[0])
+			result = append(result, DiffEntry{Status: entry.Status, Paths: []string{p}})
+		case 'C':
+			if !accounted[entry.Paths[1]] {
+				from, _ := movedPath(entry.Paths[0])
+				result = append(result, DiffEntry{Status: entry.Status, Paths: []string{from, entry.Paths[1]}})
+			}
+		case 'R':
+			from, moved := movedPath(entry.Paths[0])
+			to := entry.Paths[1]
+			switch {
+			case !moved && !accounted[to]:
+				result = append(result, entry)
+			case !moved:
+				result = append(result, DiffEntry{Status: "D", Paths: []string{from}})
+			default:
+				if !accounted[from] {
+					result = append(result, DiffEntry{Status: "D", Paths: []string{from}})
+				}
+				if !accounted[to] {
+					result = append(result, DiffEntry{Status: "A", Paths: []string{to}})
+				}
+			}
+		default:
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestApplyRecordedMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []*Move
+		diff  []DiffEntry
+		want  []DiffEntry
+	}{
+		{
+			name: "no moves",
+			diff: []DiffEntry{{"R100", []string{"a.go", "b.go"}}, {"M", []string{"c.go"}}},
+			want: []DiffEntry{{"R100", []string{"a.go", "b.go"}}, {"M", []string{"c.go"}}},
+		},
+		{
+			name:  "rename git found too",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"R100", []string{"a.go", "b.go"}}},
+		},
+		{
+			name:  "rename git saw as delete and add",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"D", []string{"a.go"}}, {"A", []string{"b.go"}}},
+		},
+		{
+			name:  "rename git paired with another path",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"R090", []string{"a.go", "c.go"}}, {"A", []string{"b.go"}}},
+			want:  []DiffEntry{{"A", []string{"c.go"}}},
+		},
+		{
+			name:  "moved path deleted before it landed",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"D", []string{"a.go"}}},
+			want:  []DiffEntry{{"D", []string{"b.go"}}},
+		},
+		{
+			name:  "folder move with a changed and a deleted file",
+			moves: []*Move{{From: "cmd", To: "tools"}},
+			diff: []DiffEntry{
+				{"R100", []string{"cmd/a.go", "tools/a.go"}},
+				{"R080", []string{"cmd/b.go", "tools/b.go"}},
+				{"D", []string{"cmd/c.go"}},
+			},
+			want: []DiffEntry{{"D", []string{"tools/c.go"}}},
+		},
+		{
+			name:  "copy of a moved file",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"R100", []string{"a.go", "b.go"}}, {"C100", []string{"a.go", "c.go"}}},
+			want:  []DiffEntry{{"C100", []string{"b.go", "c.go"}}},
+		},
+		{
+			name:  "copy onto where a move arrived",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"D", []string{"a.go"}}, {"C100", []string{"x.go", "b.go"}}},
+		},
+		{
+			name:  "modified file inside a moved folder",
+			moves: []*Move{{From: "cmd", To: "tools"}},
+			diff:  []DiffEntry{{"M", []string{"cmd/a.go"}}, {"T", []string{"cmd/link"}}},
+			want:  []DiffEntry{{"M", []string{"tools/a.go"}}, {"T", []string{"tools/link"}}},
+		},
+		{
+			name:  "unrelated rename kept",
+			moves: []*Move{{From: "a.go", To: "b.go"}},
+			diff:  []DiffEntry{{"R100", []string{"a.go", "b.go"}}, {"R100", []string{"x.go", "y.go"}}},
+			want:  []DiffEntry{{"R100", []string{"x.go", "y.go"}}},
+		},
+		{
+			name:  "chained moves",
+			moves: []*Move{{From: "a.go", To: "b.go"}, {From: "b.go", To: "c.go"}},
+			diff:  []DiffEntry{{"R100", []string{"a.go", "c.go"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := applyRecordedMoves(test.diff, test.moves)
+			if len(got) == 0 && len(test.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("applyRecordedMoves = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// ---------- newMovesRepo ----------
+// newMovesRepo creates a repository with a.go and lib/b.go and a tree
+// synced against it
+func newMovesRepo(t *testing.T) *FileTree {
+	t.Helper()
+	newTestRepo(t, map[string]string{"a.go": "package a\n", "lib/b.go": "package lib\n"})
+
+	fileTree, err := GetFileTreeFromBranch("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileTree
+}
+
+func TestSplitMoves(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T)
+		landed  bool
+	}{
+		{
+			name:    "not committed",
+			prepare: func(t *testing.T) {},
+		},
+		{
+			name: "committed",
+			prepare: func(t *testing.T) {
+				git(t, "mv", "a.go", "x.go")
+				git(t, "commit", "-q", "-m", "move")
+			},
+			landed: true,
+		},
+		{
+			name: "committed with a new file at the old place",
+			prepare: func(t *testing.T) {
+				git(t, "mv", "a.go", "x.go")
+				if err := os.WriteFile("a.go", []byte("package other\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				git(t, "add", "a.go")
+				git(t, "commit", "-q", "-m", "move and replace")
+			},
+			landed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileTree := newMovesRepo(t)
+			if err := fileTree.MoveNode("a.go", "x.go"); err != nil {
+				t.Fatal(err)
+			}
+			test.prepare(t)
+
+			landed, waiting := fileTree.SplitMoves("main")
+			want := []*Move{{From: "a.go", To: "x.go"}}
+			if test.landed && (!reflect.DeepEqual(landed, want) || len(waiting) != 0) {
+				t.Errorf("SplitMoves = %v landed, %v waiting, want the move landed", landed, waiting)
+			}
+			if !test.landed && (!reflect.DeepEqual(waiting, want) || len(landed) != 0) {
+				t.Errorf("SplitMoves = %v landed, %v waiting, want the move waiting", landed, waiting)
+			}
+		})
+	}
+}
+
+func TestRevertAndReplayMoves(t *testing.T) {
+	fileTree := newMovesRepo(t)
+	fileTree.GetNode("lib/b.go").SetDescription("Library")
+	if err := fileTree.MoveNode("lib", "pkg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileTree.MoveNode("a.go", "pkg/a.go"); err != nil {
+		t.Fatal(err)
+	}
+	waiting := fileTree.Moves
+
+	if err := fileTree.RevertMoves(waiting); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"a.go", "lib", "lib/b.go"} {
+		if !fileTree.HasNode(p) {
+			t.Errorf("%s is missing after RevertMoves", p)
+		}
+	}
+	if fileTree.HasNode("pkg") {
+		t.Error("pkg is still there after RevertMoves")
+	}
+
+	fileTree.Moves = nil
+	if dropped := fileTree.ReplayMoves(waiting); len(dropped) != 0 {
+		t.Errorf("ReplayMoves dropped %v", dropped)
+	}
+	if node := fileTree.GetNode("pkg/b.go"); node == nil || node.Description != "Library" {
+		t.Errorf("pkg/b.go = %v after ReplayMoves, want the description of lib/b.go", node)
+	}
+	if !fileTree.HasNode("pkg/a.go") || fileTree.HasNode("a.go") {
+		t.Error("a.go was not moved again by ReplayMoves")
+	}
+	if !reflect.DeepEqual(fileTree.Moves, waiting) {
+		t.Errorf("Moves = %v after ReplayMoves, want %v", fileTree.Moves, waiting)
+	}
+}
+
+func TestReplayMovesDropsMovesMainNoLongerMatches(t *testing.T) {
+	fileTree := newMovesRepo(t)
+	moves := []*Move{
+		{From: "gone.go", To: "x.go"},
+		{From: "a.go", To: "lib/b.go"},
+	}
+
+	dropped := fileTree.ReplayMoves(moves)
+	if !reflect.DeepEqual(dropped, moves) {
+		t.Errorf("ReplayMoves dropped %v, want %v", dropped, moves)
+	}
+	if len(fileTree.Moves) != 0 {
+		t.Errorf("Moves = %v, want none", fileTree.Moves)
+	}
+}
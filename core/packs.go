@@ -0,0 +1,260 @@
+package core
+
+import (
+	"embed"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PackDir holds the team's own convention packs on main, one YAML file each
+const PackDir = ".gittier/packs"
+
+//go:embed packs/*.yaml
+var builtinPacks embed.FS
+
+// Pack is a named set of rules describing the conventional paths of a
+// project layout
+type Pack struct {
+	Name  string
+	Rules []Rule
+}
+
+// Rule describes every path matching Pattern with Template. A pattern
+// ending in "/" only matches folders
+type Rule struct {
+	Pattern  string
+	Template string
+	// Pack names the convention pack the rule comes from, empty for config rules
+	Pack string
+}
+
+// packFile is the on-disk form of a pack, rules keep the order they are written in
+type packFile struct {
+	Rules yaml.MapSlice `yaml:"rules"`
+}
+
+// ---------- LoadPack ----------
+// LoadPack reads the pack called name from PackDir on main, falling back to
+// the packs that ship with gittier
+func LoadPack(name string) (*Pack, error) {
+	if !validPackName(name) {
+		return nil, fmt.Errorf("invalid pack name %q", name)
+	}
+
+	file := path.Join(PackDir, name+".yaml")
+	data, err := exec.Command("git", "show", "main:"+file).Output()
+	if err != nil {
+		file = "built-in pack " + name
+		if data, err = builtinPacks.ReadFile("packs/" + name + ".yaml"); err != nil {
+			return nil, fmt.Errorf("unknown pack %q, available packs: %s", name, strings.Join(PackNames(), ", "))
+		}
+	}
+
+	var pf packFile
+	if err := yaml.UnmarshalStrict(data, &pf); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	rules, err := parseRules(pf.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i := range rules {
+		rules[i].Pack = name
+	}
+	return &Pack{Name: name, Rules: rules}, nil
+}
+
+// ---------- PackNames ----------
+// PackNames lists the built-in packs together with the ones in PackDir on main
+func PackNames() []string {
+	seen := make(map[string]bool)
+	if entries, err := builtinPacks.ReadDir("packs"); err == nil {
+		for _, entry := range entries {
+			seen[strings.TrimSuffix(entry.Name(), ".yaml")] = true
+		}
+	}
+	if entries, err := lsTree("main:" + PackDir); err == nil {
+		for _, entry := range entries {
+			if name, ok := strings.CutSuffix(entry.Path, ".yaml"); ok && validPackName(name) {
+				seen[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ---------- validPackName ----------
+func validPackName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// ---------- parseRules ----------
+func parseRules(items yaml.MapSlice) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range items {
+		pattern, ok := item.Key.(string)
+		if !ok || !validGlob(pattern) {
+			return nil, fmt.Errorf("invalid pattern %v", item.Key)
+		}
+		template, ok := item.Value.(string)
+		if !ok || strings.TrimSpace(template) == "" {
+			return nil, fmt.Errorf("%s: the description must be a non-empty string", pattern)
+		}
+
+		rule := Rule{Pattern: pattern, Template: template}
+		// catch unknown placeholders now rather than halfway through a tree
+		if _, err := rule.Describe(NewPathNode("example", false)); err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		// ${placeholders} are left for commit, which would then stop on every
+		// matching path they can not be filled in for
+		if err := CheckPlaceholders(template, rule.example()); err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ---------- example ----------
+// example is the path a rule's placeholders are checked against: a folder
+// for a pattern that only matches folders, otherwise a file, which is the
+// stricter of the two, with the extension the pattern asks for
+func (rule Rule) example() *PathNode {
+	pattern, dirOnly := strings.CutSuffix(rule.Pattern, "/")
+	if dirOnly {
+		return NewPathNode("example", true)
+	}
+	return NewPathNode("example"+path.Ext(pattern), false)
+}
+
+// ---------- Matches ----------
+func (rule Rule) Matches(node *PathNode) bool {
+	pattern, dirOnly := strings.CutSuffix(rule.Pattern, "/")
+	if dirOnly && !node.IsDir {
+		return false
+	}
+	return matchGlob(pattern, node.Path)
+}
+
+// ---------- Describe ----------
+// Describe fills in the placeholders of the rule's template for node:
+// {name}, {stem} (the name without its extension), {parent} and {path}
+func (rule Rule) Describe(node *PathNode) (string, error) {
+	name := path.Base(node.Path)
+	parent := ""
+	if dir := parentOf(node.Path); dir != "" {
+		parent = path.Base(dir)
+	}
+	variables := map[string]string{
+		"name":   name,
+		"stem":   strings.TrimSuffix(name, path.Ext(name)),
+		"parent": parent,
+		"path":   node.Path,
+	}
+
+	return expandTemplate(rule.Template, func(variable string) (string, bool) {
+		value, ok := variables[variable]
+		return value, ok
+	})
+}
+
+// ---------- ApplyRules ----------
+// ApplyRules describes every undescribed node with the first rule matching
+// it and returns the paths that were described. Each node records the pack
+// and pattern its text came from
+func (ft *FileTree) ApplyRules(rules []Rule) ([]string, error) {
+	var described []string
+	for _, node := range ft.getSubtree("") {
+		if IsDescribed(node) {
+			continue
+		}
+		rule, ok := FirstMatchingRule(rules, node)
+		if !ok {
+			continue
+		}
+		description, err := rule.Describe(node)
+		if err != nil {
+			return described, fmt.Errorf("%s: %w", node.Path, err)
+		}
+		node.SetDescription(description)
+		node.Pack = rule.Pack + " " + rule.Pattern
+		described = append(described, node.Path)
+	}
+	return described, nil
+}
+
+// ---------- expandTemplate ----------
+// expandTemplate replaces every {variable} in template using lookup and
+// fails on variables lookup does not know. "{{" and "}}" stand for literal
+// braces, and braces around anything that is not a variable name are kept.
+// ${placeholders} and their $${ escapes are left alone for commit to fill
+// in, and filled in values are escaped so commit does not mistake them for one
+func expandTemplate(template string, lookup func(variable string) (string, bool)) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "$${"):
+			result.WriteString("$${")
+			i += 2
+		case strings.HasPrefix(template[i:], "${"):
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				end = len(template[i:]) - 1
+			}
+			result.WriteString(template[i : i+end+1])
+			i += end
+		case strings.HasPrefix(template[i:], "{{"), strings.HasPrefix(template[i:], "}}"):
+			result.WriteByte(template[i])
+			i++
+		case template[i] == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 || !isVariableName(template[i+1:i+end]) {
+				result.WriteByte('{')
+				continue
+			}
+			variable := template[i+1 : i+end]
+			value, ok := lookup(variable)
+			if !ok {
+				return "", fmt.Errorf("undefined placeholder {%s}", variable)
+			}
+			result.WriteString(strings.ReplaceAll(value, "${", "$${"))
+			i += end
+		default:
+			result.WriteByte(template[i])
+		}
+	}
+	return result.String(), nil
+}
+
+// ---------- isVariableName ----------
+func isVariableName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
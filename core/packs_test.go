@@ -0,0 +1,89 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseRulesChecksPlaceholders(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		template string
+		wantErr  string
+	}{
+		{"cmd/*/", "Command {name}, ${files} files", ""},
+		{"**/*.go", "Go source of package ${pkg}, ${lines} lines", ""},
+		{"docs/**", "Docs, last touched ${last_change_date}", ""},
+		{"**/*.go", "${files} files", "only folders have files"},
+		{"internal/**", "${dirs} folders", "only folders have files"},
+		{"**/*.md", "Part of ${pkg}", "only Go files and folders"},
+		{"cmd/*/", "Loader for ${env}", "undefined placeholder ${env}"},
+		{"cmd/*/", "Loader for {env}", "undefined placeholder {env}"},
+	}
+	for _, test := range tests {
+		_, err := parseRules(yaml.MapSlice{{Key: test.pattern, Value: test.template}})
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("%s: %q: got %v, want no error", test.pattern, test.template, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("%s: %q: got %v, want %q", test.pattern, test.template, err, test.wantErr)
+		}
+	}
+}
+
+func TestBuiltinPacksLoad(t *testing.T) {
+	entries, err := builtinPacks.ReadDir("packs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".yaml")
+		data, err := builtinPacks.ReadFile("packs/" + entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pf packFile
+		if err := yaml.UnmarshalStrict(data, &pf); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := parseRules(pf.Rules); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestApplyRulesRecordsThePack(t *testing.T) {
+	pack, err := LoadPack("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	fileTree.AddNode(NewPathNode("go.sum", false))
+	written := NewPathNode("go.mod", false)
+	written.SetDescription("Module of the CLI")
+	fileTree.AddNode(written)
+
+	described, err := fileTree.ApplyRules(pack.Rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(described) != 1 || described[0] != "go.sum" {
+		t.Fatalf("ApplyRules() = %v, want [go.sum]", described)
+	}
+
+	node := fileTree.GetNode("go.sum")
+	if node.Pack != "go go.sum" || node.Rule != "" {
+		t.Errorf("go.sum: Pack = %q, Rule = %q, want the go pack's go.sum rule and no config rule", node.Pack, node.Rule)
+	}
+	if !openForSuggestion(node) || openForSuggestion(written) {
+		t.Errorf("only the pack's text should be open for suggestions")
+	}
+
+	node.SetDescription("Checksums, do not edit")
+	if node.Pack != "" {
+		t.Errorf("Pack = %q after describing the path, want it cleared", node.Pack)
+	}
+}
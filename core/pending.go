@@ -0,0 +1,112 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultPendingMaxAge is how long a pending description may wait for its
+// path before status warns about it
+const DefaultPendingMaxAge = 30 * 24 * time.Hour
+
+// ---------- GetPending ----------
+// GetPending returns the pending description of p, or nil
+func (ft *FileTree) GetPending(p string) *Pending {
+	for _, pending := range ft.Pending {
+		if pending.Path == p {
+			return pending
+		}
+	}
+	return nil
+}
+
+// ---------- AddPending ----------
+// AddPending describes a path that is not on main yet, replacing any older
+// pending description of the same path
+func (ft *FileTree) AddPending(p, description string, added time.Time) error {
+	if ft.HasNode(p) {
+		return fmt.Errorf("%s is already in the filetree", p)
+	}
+
+	if existing := ft.GetPending(p); existing != nil {
+		existing.Description = description
+		existing.Added = added
+		return nil
+	}
+
+	ft.Pending = append(ft.Pending, &Pending{Path: p, Description: description, Added: added})
+	sort.Slice(ft.Pending, func(i, j int) bool {
+		return ft.Pending[i].Path < ft.Pending[j].Path
+	})
+	return nil
+}
+
+// ---------- AttachPending ----------
+// AttachPending moves every pending description whose path is now in the
+// tree onto its node and returns the paths that were attached. A pending
+// description wins over one that came along with a rename or a tombstone,
+// since it was written for exactly that path. One whose placeholders turn
+// out not to fit the path, e.g. ${files} on what arrived as a file, stays
+// pending and is returned in held
+func (ft *FileTree) AttachPending() (attached []string, held []error) {
+	var waiting []*Pending
+	for _, pending := range ft.Pending {
+		node := ft.GetNode(pending.Path)
+		if node == nil {
+			waiting = append(waiting, pending)
+			continue
+		}
+		if err := CheckPlaceholders(pending.Description, node); err != nil {
+			held = append(held, fmt.Errorf("%s: %w", pending.Path, err))
+			waiting = append(waiting, pending)
+			continue
+		}
+		node.SetDescription(pending.Description)
+		attached = append(attached, pending.Path)
+	}
+
+	ft.Pending = waiting
+	return attached, held
+}
+
+// ---------- OverduePending ----------
+// OverduePending lists the pending descriptions that were added more than
+// maxAge before now
+func (ft *FileTree) OverduePending(maxAge time.Duration, now time.Time) []*Pending {
+	if maxAge == 0 {
+		maxAge = DefaultPendingMaxAge
+	}
+
+	var overdue []*Pending
+	for _, pending := range ft.Pending {
+		if now.Sub(pending.Added) > maxAge {
+			overdue = append(overdue, pending)
+		}
+	}
+	return overdue
+}
+
+// ---------- DropPending ----------
+// DropPending forgets the pending descriptions of the given paths and
+// returns how many were removed
+func (ft *FileTree) DropPending(paths []string) (int, error) {
+	drop := make(map[string]bool)
+	for _, p := range paths {
+		if ft.GetPending(p) == nil {
+			return 0, fmt.Errorf("no pending description for %s", p)
+		}
+		drop[p] = true
+	}
+
+	var kept []*Pending
+	for _, pending := range ft.Pending {
+		if !drop[pending.Path] {
+			kept = append(kept, pending)
+		}
+	}
+
+	count := len(ft.Pending) - len(kept)
+	ft.Pending = kept
+	return count, nil
+}
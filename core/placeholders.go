@@ -0,0 +1,379 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Placeholders are the variables a description may use as ${name}. They are
+// filled in from main when the descriptions are published
+var Placeholders = map[string]string{
+	"name":             "name of the file or folder",
+	"path":             "full path of the file or folder",
+	"files":            "number of files anywhere below a folder",
+	"dirs":             "number of folders anywhere below a folder",
+	"lines":            "lines of text in a file, or in all files below a folder",
+	"size":             "size of a file, or of all files below a folder, e.g. 12.3 KB",
+	"pkg":              "Go package of a .go file, or of the Go files directly in a folder",
+	"last_change_date": "date of the last commit on main touching the path, e.g. 2024-05-01",
+	"last_author":      "author of the last commit on main touching the path",
+	"last_commit":      "short hash of the last commit on main touching the path",
+}
+
+// ---------- PlaceholderNames ----------
+func PlaceholderNames() []string {
+	names := make([]string, 0, len(Placeholders))
+	for name := range Placeholders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ---------- CheckPlaceholders ----------
+// CheckPlaceholders fails when description uses a placeholder that does not
+// exist or can never have a value for node, so the mistake is caught when
+// the description is written rather than when it is published. With a nil
+// node, for a path that is not in the tree yet, only the names are checked
+func CheckPlaceholders(description string, node *PathNode) error {
+	var problems []error
+	_, err := expandPlaceholders(description, func(variable string) (string, bool) {
+		if _, ok := Placeholders[variable]; !ok {
+			return "", false
+		}
+		if node != nil {
+			if err := placeholderApplies(node, variable); err != nil {
+				problems = append(problems, fmt.Errorf("${%s}: %w", variable, err))
+			}
+		}
+		return "", true
+	})
+	if err != nil {
+		return fmt.Errorf("%w (known placeholders: %s)", err, strings.Join(PlaceholderNames(), ", "))
+	}
+	return errors.Join(problems...)
+}
+
+// ---------- PlaceholderLookalikes ----------
+// PlaceholderLookalikes lists the {name} spellings of placeholders in
+// description. Only ${name} is filled in, so they are published as written,
+// but they were most likely meant as placeholders
+func PlaceholderLookalikes(description string) []string {
+	var found []string
+	for i := 0; i < len(description); i++ {
+		if description[i] != '{' || i > 0 && (description[i-1] == '$' || description[i-1] == '{') {
+			continue
+		}
+		end := strings.IndexByte(description[i:], '}')
+		if end < 0 {
+			break
+		}
+		if _, ok := Placeholders[description[i+1:i+end]]; ok {
+			found = append(found, description[i:i+end+1])
+		}
+	}
+	return found
+}
+
+// ---------- placeholderApplies ----------
+func placeholderApplies(node *PathNode, variable string) error {
+	switch variable {
+	case "files", "dirs":
+		if !node.IsDir {
+			return errors.New("only folders have files and folders below them")
+		}
+	case "pkg":
+		if !node.IsDir && path.Ext(node.Path) != ".go" {
+			return errors.New("only Go files and folders have a package")
+		}
+	}
+	return nil
+}
+
+// ---------- ExpandPlaceholders ----------
+// ExpandPlaceholders fills in the ${placeholders} of every description in ft
+// from branch. A description that does not expand, because a placeholder is
+// unknown or has no value for its path, is left as written and returned as
+// one of the problems. err is only set when branch could not be read
+func ExpandPlaceholders(ft *FileTree, branch string) (problems []error, err error) {
+	uses := make(map[*PathNode][]string)
+	var nodes []*PathNode
+	for _, node := range GetDfsOrder(ft) {
+		if !IsDescribed(node) || !strings.Contains(node.Description, "${") {
+			continue
+		}
+		if err := CheckPlaceholders(node.Description, node); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", node.Path, err))
+			continue
+		}
+
+		var variables []string
+		expandPlaceholders(node.Description, func(variable string) (string, bool) {
+			variables = append(variables, variable)
+			return "", true
+		})
+		uses[node] = variables
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return problems, nil
+	}
+
+	facts, err := gatherFacts(ft, branch, uses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		values := make(map[string]string)
+		var missing []error
+		for _, variable := range uses[node] {
+			value, err := facts.value(node, variable)
+			if err != nil {
+				missing = append(missing, fmt.Errorf("${%s}: %w", variable, err))
+				continue
+			}
+			values[variable] = value
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Errorf("%s: %w", node.Path, errors.Join(missing...)))
+			continue
+		}
+
+		node.Description, _ = expandPlaceholders(node.Description, func(variable string) (string, bool) {
+			value, ok := values[variable]
+			return value, ok
+		})
+	}
+	return problems, nil
+}
+
+// ---------- expandPlaceholders ----------
+// expandPlaceholders replaces every ${variable} in text using lookup and
+// fails on variables lookup does not know. "$${" stands for a literal "${",
+// and everything else, braces included, is kept as written
+func expandPlaceholders(text string, lookup func(variable string) (string, bool)) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "$${"):
+			result.WriteString("${")
+			i += 2
+		case strings.HasPrefix(text[i:], "${"):
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 || !isVariableName(text[i+2:i+end]) {
+				result.WriteByte('$')
+				continue
+			}
+			variable := text[i+2 : i+end]
+			value, ok := lookup(variable)
+			if !ok {
+				return "", fmt.Errorf("undefined placeholder ${%s}", variable)
+			}
+			result.WriteString(value)
+			i += end
+		default:
+			result.WriteByte(text[i])
+		}
+	}
+	return result.String(), nil
+}
+
+// placeholderFacts holds what was read from main to fill in placeholders
+type placeholderFacts struct {
+	ft      *FileTree
+	history map[string]CommitInfo
+	stats   map[string]blobStats
+	heads   map[string][]byte
+}
+
+// blobStats is what ${lines} and ${size} need from a blob
+type blobStats struct {
+	size   int
+	lines  int
+	binary bool
+}
+
+// ---------- gatherFacts ----------
+// gatherFacts reads the history and blobs the used placeholders need, once
+// for all descriptions
+func gatherFacts(ft *FileTree, branch string, uses map[*PathNode][]string) (*placeholderFacts, error) {
+	facts := &placeholderFacts{ft: ft, stats: map[string]blobStats{}, heads: map[string][]byte{}}
+
+	var historyPaths, counted, heads []string
+	for node, variables := range uses {
+		for _, variable := range variables {
+			switch variable {
+			case "last_change_date", "last_author", "last_commit":
+				historyPaths = append(historyPaths, node.Path)
+			case "lines", "size":
+				for _, file := range facts.files(node) {
+					counted = append(counted, file.ObjectID)
+				}
+			case "pkg":
+				for _, file := range facts.goFiles(node) {
+					heads = append(heads, file.ObjectID)
+				}
+			}
+		}
+	}
+
+	if len(historyPaths) > 0 {
+		history, err := LastCommits(branch, historyPaths)
+		if err != nil {
+			return nil, err
+		}
+		facts.history = history
+	}
+	if len(counted) > 0 {
+		// only the counts are kept, however large the files are
+		err := scanBlobs(counted, func(id string, size int, content io.Reader) error {
+			stats := blobStats{size: size}
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := content.Read(buf)
+				stats.lines += bytes.Count(buf[:n], []byte("\n"))
+				stats.binary = stats.binary || bytes.IndexByte(buf[:n], 0) >= 0
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+			}
+			facts.stats[id] = stats
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(heads) > 0 {
+		contents, err := readBlobs(heads, blobHead)
+		if err != nil {
+			return nil, err
+		}
+		facts.heads = contents
+	}
+	return facts, nil
+}
+
+// ---------- value ----------
+func (facts *placeholderFacts) value(node *PathNode, variable string) (string, error) {
+	switch variable {
+	case "name":
+		return path.Base(node.Path), nil
+	case "path":
+		return node.Path, nil
+	case "files", "dirs":
+		if err := placeholderApplies(node, variable); err != nil {
+			return "", err
+		}
+		count := 0
+		for _, child := range facts.ft.getSubtree(node.Path) {
+			if child != node && child.IsDir == (variable == "dirs") {
+				count++
+			}
+		}
+		return fmt.Sprint(count), nil
+	case "lines":
+		lines := 0
+		for _, file := range facts.files(node) {
+			// binary files have no lines to speak of
+			if stats := facts.stats[file.ObjectID]; !stats.binary {
+				lines += stats.lines
+			}
+		}
+		return fmt.Sprint(lines), nil
+	case "size":
+		size := 0
+		for _, file := range facts.files(node) {
+			size += facts.stats[file.ObjectID].size
+		}
+		return humanSize(size), nil
+	case "pkg":
+		for _, file := range facts.goFiles(node) {
+			if pkg := goPackageName(facts.heads[file.ObjectID]); pkg != "" {
+				return pkg, nil
+			}
+		}
+		return "", errors.New("no Go package here")
+	case "last_change_date", "last_author", "last_commit":
+		commit, ok := facts.history[node.Path]
+		if !ok {
+			return "", errors.New("no commit on main touches this path")
+		}
+		switch variable {
+		case "last_change_date":
+			return commit.Date, nil
+		case "last_author":
+			return commit.Author, nil
+		default:
+			return commit.ShortHash, nil
+		}
+	}
+	return "", fmt.Errorf("undefined placeholder ${%s}", variable)
+}
+
+// ---------- files ----------
+// files lists the regular files at or below node
+func (facts *placeholderFacts) files(node *PathNode) []*PathNode {
+	var files []*PathNode
+	for _, child := range facts.ft.getSubtree(node.Path) {
+		if (child.Kind == KindFile || child.Kind == KindExecutable) && child.ObjectID != "" {
+			files = append(files, child)
+		}
+	}
+	return files
+}
+
+// ---------- goFiles ----------
+// goFiles lists node itself when it is a Go file, or the Go files directly
+// in it, tests last since they may belong to an external test package
+func (facts *placeholderFacts) goFiles(node *PathNode) []*PathNode {
+	candidates := []*PathNode{node}
+	if node.IsDir {
+		candidates = facts.ft.GetChildNodes(node.Path)
+	}
+
+	var files, tests []*PathNode
+	for _, file := range candidates {
+		if file.IsDir || file.ObjectID == "" || path.Ext(file.Path) != ".go" {
+			continue
+		}
+		if strings.HasSuffix(file.Path, "_test.go") {
+			tests = append(tests, file)
+		} else {
+			files = append(files, file)
+		}
+	}
+	return append(files, tests...)
+}
+
+// ---------- goPackageName ----------
+func goPackageName(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		name, ok := strings.CutPrefix(strings.TrimSpace(line), "package ")
+		if fields := strings.Fields(name); ok && len(fields) > 0 {
+			return strings.TrimSuffix(fields[0], "_test")
+		}
+	}
+	return ""
+}
+
+// ---------- humanSize ----------
+func humanSize(size int) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	}
+}
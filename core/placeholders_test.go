@@ -0,0 +1,127 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ---------- placeholderTestTree ----------
+func placeholderTestTree() *FileTree {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	fileTree.AddNode(NewPathNode("cmd", true))
+	fileTree.AddNode(NewPathNode("cmd/main.go", false))
+	fileTree.AddNode(NewPathNode("README.md", false))
+	return fileTree
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	fileTree := placeholderTestTree()
+	tests := []struct {
+		description string
+		path        string
+		wantErr     string
+	}{
+		{"Commands (${files} files)", "cmd", ""},
+		{"Entry point of ${pkg}", "cmd/main.go", ""},
+		{"Docs, last touched ${last_change_date}", "README.md", ""},
+		{"Costs $${files}, not {files} or ${ files }", "README.md", ""},
+		{"Entry ${files}", "cmd/main.go", "only folders have files"},
+		{"Docs for ${pkg}", "README.md", "only Go files and folders"},
+		{"Loader for ${env}", "cmd", "undefined placeholder ${env}"},
+		// the kind of a path that is not on main yet is unknown
+		{"Entry ${files}", "", ""},
+		{"Loader for ${env}", "", "undefined placeholder ${env}"},
+	}
+	for _, test := range tests {
+		var node *PathNode
+		if test.path != "" {
+			node = fileTree.GetNode(test.path)
+		}
+		err := CheckPlaceholders(test.description, node)
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("CheckPlaceholders(%q, %q) = %v, want nil", test.description, test.path, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("CheckPlaceholders(%q, %q) = %v, want %q", test.description, test.path, err, test.wantErr)
+		}
+	}
+}
+
+func TestExpandPlaceholdersLeavesBadDescriptionsAsWritten(t *testing.T) {
+	fileTree := placeholderTestTree()
+	fileTree.GetNode("cmd").SetDescription("The ${name} folder")
+	fileTree.GetNode("cmd/main.go").SetDescription("Entry ${files}")
+	fileTree.GetNode("README.md").SetDescription("Read ${me}")
+
+	problems, err := ExpandPlaceholders(fileTree, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("got problems %v, want one for cmd/main.go and one for README.md", problems)
+	}
+
+	want := map[string]string{
+		"cmd":         "The cmd folder",
+		"cmd/main.go": "Entry ${files}",
+		"README.md":   "Read ${me}",
+	}
+	for p, description := range want {
+		if got := fileTree.GetNode(p).Description; got != description {
+			t.Errorf("%s: description = %q, want %q", p, got, description)
+		}
+	}
+}
+
+func TestAttachPendingHoldsPlaceholdersThatDoNotFit(t *testing.T) {
+	fileTree := placeholderTestTree()
+	fileTree.Pending = []*Pending{
+		{Path: "cmd", Description: "Commands (${files} files)", Added: time.Now()},
+		{Path: "cmd/main.go", Description: "Entry (${files} files)", Added: time.Now()},
+	}
+
+	attached, held := fileTree.AttachPending()
+	if len(attached) != 1 || attached[0] != "cmd" {
+		t.Errorf("attached = %v, want [cmd]", attached)
+	}
+	if len(held) != 1 || !strings.HasPrefix(held[0].Error(), "cmd/main.go: ") {
+		t.Errorf("held = %v, want cmd/main.go", held)
+	}
+	if len(fileTree.Pending) != 1 || fileTree.Pending[0].Path != "cmd/main.go" {
+		t.Errorf("still pending = %v, want cmd/main.go", fileTree.Pending)
+	}
+	if IsDescribed(fileTree.GetNode("cmd/main.go")) {
+		t.Errorf("cmd/main.go was described with a placeholder it can not have")
+	}
+}
+
+func TestImportRecordsRejectsBadPlaceholders(t *testing.T) {
+	fileTree := placeholderTestTree()
+	records := []Record{
+		{Path: "cmd", Description: "Commands (${files} files)"},
+		{Path: "cmd/main.go", Description: "Entry ${files}"},
+	}
+
+	_, err := ImportRecords(fileTree, records, false)
+	if err == nil || !strings.Contains(err.Error(), "cmd/main.go: ${files}") {
+		t.Fatalf("ImportRecords() error = %v, want cmd/main.go rejected", err)
+	}
+}
+
+func TestPlaceholderLookalikes(t *testing.T) {
+	tests := map[string][]string{
+		"Core ({files} files, {lines} LOC)": {"{files}", "{lines}"},
+		"Core (${files} files)":             nil,
+		"Costs $${files}":                   nil,
+		"Renders {{files}} and {env}":       nil,
+		"Loader for {env} configs":          nil,
+		"Unclosed {files":                   nil,
+	}
+	for description, want := range tests {
+		got := PlaceholderLookalikes(description)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("PlaceholderLookalikes(%q) = %v, want %v", description, got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// RootCommitMessage is the message commit leaves on the repository root
+const RootCommitMessage = "project root"
+
+// commitTitleLimit is where GitHub cuts a commit title and appends an ellipsis
+const commitTitleLimit = 72
+
+// ListingEntry is a single row of a directory listing as GitHub shows it
+type ListingEntry struct {
+	// Name may span several directories when single-child chains are collapsed
+	Name    string
+	Path    string
+	IsDir   bool
+	Message string
+}
+
+// ---------- CommitTitle ----------
+// CommitTitle returns the part of a description GitHub shows next to a path:
+// the first line, cut to 69 characters plus an ellipsis when over 72
+func CommitTitle(description string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	title = strings.TrimSpace(title)
+
+	if utf8.RuneCountInString(title) <= commitTitleLimit {
+		return title
+	}
+	runes := []rune(title)
+	return string(runes[:commitTitleLimit-3]) + "…"
+}
+
+// ---------- GetListing ----------
+// GetListing returns the entries of dir with folders first, each group
+// sorted alphabetically, and single-child folder chains collapsed
+func GetListing(ft *FileTree, dir string) []ListingEntry {
+	var entries []ListingEntry
+	for _, child := range ft.GetChildNodes(dir) {
+		entry := ListingEntry{
+			Name:  path.Base(child.Path),
+			Path:  child.Path,
+			IsDir: child.IsDir,
+		}
+
+		// a folder whose only entry is another folder is shown as a/b/c
+		node := child
+		for node.IsDir {
+			children := ft.GetChildNodes(node.Path)
+			if len(children) != 1 || !children[0].IsDir {
+				break
+			}
+			node = children[0]
+			entry.Name += "/" + path.Base(node.Path)
+			entry.Path = node.Path
+		}
+
+		// the deepest folder was described last, so its commit is the one shown
+		entry.Message = CommitTitle(node.Description)
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries
+}
+
+// previewPage is the data passed to previewTemplate
+type previewPage struct {
+	Repo        string
+	Breadcrumbs []previewLink
+	Message     string
+	Entries     []previewRow
+}
+
+type previewLink struct {
+	Name string
+	Href string
+}
+
+type previewRow struct {
+	ListingEntry
+	Href string
+}
+
+// ---------- WritePreview ----------
+// WritePreview writes one index.html per directory of ft into outDir
+func WritePreview(ft *FileTree, repo, outDir string) error {
+	dirs := []string{""}
+	for _, node := range GetDfsOrder(ft) {
+		if node.IsDir {
+			dirs = append(dirs, node.Path)
+		}
+	}
+
+	for _, dir := range dirs {
+		if err := writePreviewPage(ft, repo, outDir, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------- writePreviewPage ----------
+func writePreviewPage(ft *FileTree, repo, outDir, dir string) error {
+	// links are relative so the preview works straight from disk, and start
+	// with a dot so a name like "a:b" is not taken for a URL scheme
+	toRoot := strings.Repeat("../", strings.Count(dir, "/")+1)
+	if dir == "" {
+		toRoot = "./"
+	}
+
+	page := previewPage{
+		Repo:        repo,
+		Breadcrumbs: []previewLink{{Name: repo, Href: toRoot + "index.html"}},
+		Message:     RootCommitMessage,
+	}
+
+	if dir != "" {
+		segments := strings.Split(dir, "/")
+		for i, segment := range segments {
+			page.Breadcrumbs = append(page.Breadcrumbs, previewLink{
+				Name: segment,
+				Href: toRoot + escapePath(strings.Join(segments[:i+1], "/")) + "/index.html",
+			})
+		}
+		if node := ft.GetNode(dir); node != nil {
+			page.Message = CommitTitle(node.Description)
+		}
+	}
+
+	for _, entry := range GetListing(ft, dir) {
+		row := previewRow{ListingEntry: entry}
+		if entry.IsDir {
+			row.Href = toRoot + escapePath(entry.Path) + "/index.html"
+		}
+		page.Entries = append(page.Entries, row)
+	}
+
+	pageDir := filepath.Join(outDir, filepath.FromSlash(dir))
+	if err := os.MkdirAll(pageDir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(pageDir, "index.html"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := previewTemplate.Execute(file, page); err != nil {
+		return fmt.Errorf("failed to render preview of %s: %w", displayPath(dir), err)
+	}
+	return nil
+}
+
+// ---------- escapePath ----------
+// escapePath escapes every segment of p for use in a link, so names with
+// "#", "?", "%" or spaces point at their own folder
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Repo}} · gittier preview</title>
+<style>
+  body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; color: #1f2328; margin: 32px auto; max-width: 1012px; }
+  a { color: #0969da; text-decoration: none; }
+  a:hover { text-decoration: underline; }
+  .crumbs { font-size: 20px; margin-bottom: 16px; }
+  .crumbs a:last-child { font-weight: 600; color: #1f2328; }
+  table { width: 100%; border: 1px solid #d0d7de; border-radius: 6px; border-collapse: separate; border-spacing: 0; table-layout: fixed; }
+  th { background: #f6f8fa; text-align: left; font-weight: 400; padding: 12px 16px; border-bottom: 1px solid #d0d7de; color: #59636e; }
+  td { padding: 8px 16px; border-top: 1px solid #d0d7de; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+  tr:nth-child(2) td { border-top: none; }
+  td.name { width: 35%; }
+  td.message { color: #59636e; }
+  .icon { display: inline-block; width: 16px; margin-right: 8px; color: #54aeff; }
+  .file .icon { color: #59636e; }
+</style>
+</head>
+<body>
+<div class="crumbs">{{range $i, $c := .Breadcrumbs}}{{if $i}} / {{end}}<a href="{{$c.Href}}">{{$c.Name}}</a>{{end}}</div>
+<table>
+  <tr><th colspan="2">{{.Message}}</th></tr>
+  {{- range .Entries}}
+  <tr class="{{if .IsDir}}dir{{else}}file{{end}}">
+    <td class="name" title="{{.Path}}"><span class="icon">{{if .IsDir}}&#128193;{{else}}&#128196;{{end}}</span>{{if .Href}}<a href="{{.Href}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td>
+    <td class="message" title="{{.Message}}">{{.Message}}</td>
+  </tr>
+  {{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEscapePath(t *testing.T) {
+	tests := map[string]string{
+		"cmd":                "cmd",
+		"cmd/sub":            "cmd/sub",
+		"with space/a#b":     "with%20space/a%23b",
+		"what?/100%":         "what%3F/100%25",
+		"café/日本":            "caf%C3%A9/%E6%97%A5%E6%9C%AC",
+		`quote"s/back\slash`: "quote%22s/back%5Cslash",
+	}
+	for p, want := range tests {
+		if got := escapePath(p); got != want {
+			t.Errorf("escapePath(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestWritePreviewLinksUnusualFolders(t *testing.T) {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	for _, p := range []string{"a#b", "a#b/what?", "a#b/x y", "100%", "with space", "a:b"} {
+		fileTree.AddNode(NewPathNode(p, true))
+	}
+
+	outDir := t.TempDir()
+	if err := WritePreview(fileTree, "repo", outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, href := range []string{`href="./a%23b/index.html"`, `href="./100%25/index.html"`, `href="./with%20space/index.html"`, `href="./a:b/index.html"`} {
+		if !strings.Contains(string(root), href) {
+			t.Errorf("root page is missing %s", href)
+		}
+	}
+
+	nested, err := os.ReadFile(filepath.Join(outDir, "a#b", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, href := range []string{`href="../index.html"`, `href="../a%23b/index.html"`, `href="../a%23b/what%3F/index.html"`} {
+		if !strings.Contains(string(nested), href) {
+			t.Errorf("page of a#b is missing %s", href)
+		}
+	}
+}
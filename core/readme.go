@@ -0,0 +1,156 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GeneratedReadmeHeader marks READMEs gittier owns and may overwrite
+const GeneratedReadmeHeader = "<!-- generated by gittier on the showcase branch, edits will be overwritten -->"
+
+// ---------- RenderReadme ----------
+// RenderReadme builds the overview of a directory: its description and a
+// table of its children
+func RenderReadme(ft *FileTree, dir *PathNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(path.Base(dir.Path)))
+	fmt.Fprintf(&b, "%s\n\n", markdownEscaper.Replace(strings.TrimSpace(dir.Description)))
+	b.WriteString(renderReadmeTable(ft, dir))
+	return b.String()
+}
+
+// ---------- renderReadmeTable ----------
+func renderReadmeTable(ft *FileTree, dir *PathNode) string {
+	var b strings.Builder
+	b.WriteString("| Name | Description |\n")
+	b.WriteString("| --- | --- |\n")
+
+	for _, child := range ft.GetChildNodes(dir.Path) {
+		name := path.Base(child.Path)
+		link := url.PathEscape(name)
+		if child.IsDir {
+			name += "/"
+		}
+
+		description := ""
+		if IsDescribed(child) {
+			description = markdownEscaper.Replace(CommitTitle(child.Description))
+		}
+
+		// a table ends a cell at every unescaped |, code spans included
+		name = strings.ReplaceAll(codeSpan(name), "|", `\|`)
+		fmt.Fprintf(&b, "| [%s](%s) | %s |\n", name, link, description)
+	}
+	return b.String()
+}
+
+// ---------- resolveReadmeConflicts ----------
+// resolveReadmeConflicts settles a failed merge into FileTreeBranch when
+// every conflict is a README gittier wrote or injected into. Main's side
+// wins, GenerateReadmes then skips or re-injects it, and the merge is
+// committed. It reports false and leaves the merge alone when any other
+// file conflicts
+func resolveReadmeConflicts() (bool, error) {
+	conflicts, err := unmergedPaths()
+	if err != nil || len(conflicts) == 0 {
+		return false, err
+	}
+
+	for _, p := range conflicts {
+		if path.Base(p) != "README.md" {
+			return false, nil
+		}
+		ours, err := exec.Command("git", "show", ":2:"+p).Output()
+		if err != nil {
+			return false, nil
+		}
+		if !strings.HasPrefix(string(ours), GeneratedReadmeHeader) && !strings.Contains(string(ours), InjectStartMarker) {
+			return false, nil
+		}
+	}
+
+	for _, p := range conflicts {
+		// main deleted the file when it has no stage of its own
+		resolve := []string{"checkout", "--theirs", "--", p}
+		if exec.Command("git", "cat-file", "-e", ":3:"+p).Run() != nil {
+			resolve = []string{"rm", "-q", "--", p}
+		}
+		if output, err := exec.Command("git", append([]string{"--literal-pathspecs"}, resolve...)...).CombinedOutput(); err != nil {
+			return false, fmt.Errorf("failed to take %s from main: %w\n%s", p, err, output)
+		}
+		if resolve[0] == "checkout" {
+			if err := Stage(p); err != nil {
+				return false, fmt.Errorf("failed to stage %s: %w", p, err)
+			}
+		}
+	}
+
+	if output, err := exec.Command("git", "commit", "--no-edit").CombinedOutput(); err != nil {
+		return false, fmt.Errorf("failed to commit the merge: %w\n%s", err, output)
+	}
+	return true, nil
+}
+
+// ---------- GenerateReadmes ----------
+// GenerateReadmes writes a README.md into every described directory of the
+// work tree. It only runs on FileTreeBranch so the files never reach main.
+// Hand-written READMEs are left alone unless they contain the gittier
+// markers, in which case only the table between the markers is replaced
+func GenerateReadmes(ft *FileTree) ([]string, error) {
+	currentBranch, err := GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	if currentBranch != FileTreeBranch {
+		return nil, fmt.Errorf("READMEs are only generated on the %s branch, not %s", FileTreeBranch, currentBranch)
+	}
+
+	var written []string
+	for _, node := range GetDfsOrder(ft) {
+		if !node.IsDir || !IsDescribed(node) {
+			continue
+		}
+
+		dir := filepath.FromSlash(node.Path)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		readmePath := filepath.Join(dir, "README.md")
+		content := GeneratedReadmeHeader + "\n" + RenderReadme(ft, node)
+
+		existing, err := os.ReadFile(readmePath)
+		switch {
+		case os.IsNotExist(err):
+			// nothing there yet, write the full README
+		case err != nil:
+			return written, fmt.Errorf("failed to read %s: %w", readmePath, err)
+		case strings.HasPrefix(string(existing), GeneratedReadmeHeader):
+			// ours from a previous commit, regenerate it
+		case strings.Contains(string(existing), InjectStartMarker):
+			content, err = InjectBetweenMarkers(string(existing), renderReadmeTable(ft, node))
+			if err != nil {
+				fmt.Printf("Warning: skipping %s: %v\n", readmePath, err)
+				continue
+			}
+		default:
+			fmt.Printf("Skipping hand-written %s\n", readmePath)
+			continue
+		}
+
+		if string(existing) == content {
+			continue
+		}
+		if err := os.WriteFile(readmePath, []byte(content), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", readmePath, err)
+		}
+		written = append(written, filepath.ToSlash(readmePath))
+	}
+
+	return written, nil
+}
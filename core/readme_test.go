@@ -0,0 +1,124 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ---------- readmeTestTree ----------
+func readmeTestTree() *FileTree {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	dir := NewPathNode("cmd", true)
+	dir.Description = "Command line entry points"
+	fileTree.AddNode(dir)
+	fileTree.AddNode(NewPathNode("cmd/main.go", false))
+	return fileTree
+}
+
+func TestMergeKeepsReadmeMainAddedOverGenerated(t *testing.T) {
+	newTestRepo(t, map[string]string{"cmd/main.go": "package main\n"})
+	git(t, "branch", FileTreeBranch)
+	git(t, "switch", "-q", FileTreeBranch)
+
+	readmes, err := GenerateReadmes(readmeTestTree())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readmes) != 1 || readmes[0] != "cmd/README.md" {
+		t.Fatalf("GenerateReadmes() = %v, want [cmd/README.md]", readmes)
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "Generate directory READMEs")
+
+	git(t, "switch", "-q", "main")
+	handWritten := "# cmd\n\nWritten on main.\n"
+	if err := os.WriteFile("cmd/README.md", []byte(handWritten), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "add a README")
+	git(t, "switch", "-q", FileTreeBranch)
+
+	// a second round has to merge cleanly as well
+	for round := 0; round < 2; round++ {
+		if err := MergeBranch(FileTreeBranch, "main"); err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		if status := git(t, "status", "--porcelain"); status != "" {
+			t.Fatalf("round %d: work tree not clean after merge:\n%s", round, status)
+		}
+
+		readmes, err := GenerateReadmes(readmeTestTree())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(readmes) != 0 {
+			t.Errorf("round %d: GenerateReadmes() rewrote %v, want main's README left alone", round, readmes)
+		}
+	}
+
+	content, err := os.ReadFile("cmd/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != handWritten {
+		t.Errorf("cmd/README.md = %q, want main's version", content)
+	}
+}
+
+func TestMergeStillStopsOnOtherConflicts(t *testing.T) {
+	newTestRepo(t, map[string]string{"cmd/main.go": "package main\n"})
+	git(t, "branch", FileTreeBranch)
+	git(t, "switch", "-q", FileTreeBranch)
+
+	if err := os.WriteFile("cmd/main.go", []byte("package showcase\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "commit", "-q", "-am", "edit on showcase")
+
+	git(t, "switch", "-q", "main")
+	if err := os.WriteFile("cmd/main.go", []byte("package cmd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "commit", "-q", "-am", "edit on main")
+	git(t, "switch", "-q", FileTreeBranch)
+
+	err := MergeBranch(FileTreeBranch, "main")
+	if err == nil || !strings.Contains(err.Error(), "CONFLICT") {
+		t.Fatalf("MergeBranch() error = %v, want the conflict reported", err)
+	}
+	if status := git(t, "status", "--porcelain"); status != "" {
+		t.Errorf("merge was not aborted:\n%s", status)
+	}
+}
+
+func TestRenderReadmeEscapesNamesAndDescriptions(t *testing.T) {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	dir := NewPathNode("cmd", true)
+	dir.Description = "Runs *all* commands"
+	fileTree.AddNode(dir)
+
+	described := map[string]string{
+		"cmd/odd`name.go": "plain",
+		"cmd/a|b.go":      "reads <stdin> | writes `stdout`",
+		"cmd/main.go":     "snake_case entry",
+	}
+	for p, description := range described {
+		node := NewPathNode(p, false)
+		node.Description = description
+		fileTree.AddNode(node)
+	}
+
+	readme := RenderReadme(fileTree, dir)
+	for _, line := range []string{
+		"Runs \\*all\\* commands\n",
+		"| [`a\\|b.go`](a%7Cb.go) | reads \\<stdin> \\| writes \\`stdout\\` |\n",
+		"| [`main.go`](main.go) | snake\\_case entry |\n",
+		"| [`` odd`name.go ``](odd%60name.go) | plain |\n",
+	} {
+		if !strings.Contains(readme, line) {
+			t.Errorf("README is missing %q:\n%s", line, readme)
+		}
+	}
+}
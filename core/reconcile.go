@@ -0,0 +1,243 @@
+package core
+
+import (
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ReconcileReport lists how the described paths of the old tree were carried
+// over when there was no history to diff against
+type ReconcileReport struct {
+	// ByPath counts descriptions kept because the path still exists
+	ByPath int
+	// ByObject holds paths matched to a new path with identical content
+	ByObject []PathMove
+	// BySimilarity holds paths matched to a new path with similar content
+	BySimilarity []PathMove
+	// ByFolder holds folders matched through the files inside them
+	ByFolder []PathMove
+	// Ambiguous holds described paths with more than one equally good match,
+	// they are kept as tombstones
+	Ambiguous []string
+	// Lost holds described paths nothing could be matched to, they are kept
+	// as tombstones
+	Lost []string
+	// Restored holds paths that got the description of an older tombstone back
+	Restored []PathMove
+}
+
+// ---------- CommitExists ----------
+func CommitExists(commit string) bool {
+	if commit == "" {
+		return false
+	}
+	cmd := exec.Command("git", "cat-file", "-e", commit+"^{commit}")
+	return cmd.Run() == nil
+}
+
+// ---------- objectExists ----------
+func objectExists(object string) bool {
+	cmd := exec.Command("git", "cat-file", "-e", object)
+	return cmd.Run() == nil
+}
+
+// ---------- ReconcileFileTree ----------
+// ReconcileFileTree rebuilds the tree from currentFileTree and carries the
+// descriptions of oldFileTree over without a diff: first by path, then by
+// identical object id, then by content similarity of at least threshold
+// percent against files with the same name or in the same folder
+func ReconcileFileTree(oldFileTree, currentFileTree *FileTree, threshold int) (*FileTree, *ReconcileReport) {
+	if threshold == 0 {
+		threshold = DefaultRenameThreshold
+	}
+	report := &ReconcileReport{}
+
+	// new path -> old node it takes its description from
+	matched := make(map[string]*PathNode)
+	used := make(map[string]bool)
+	var unmatched []*PathNode
+
+	for _, node := range GetDfsOrder(oldFileTree) {
+		// rule descriptions are worked out again for the new paths
+		if !IsDescribed(node) || node.Rule != "" {
+			continue
+		}
+		if current := currentFileTree.GetNode(node.Path); current != nil && current.IsDir == node.IsDir {
+			matched[node.Path] = node
+			used[node.Path] = true
+			report.ByPath++
+			continue
+		}
+		unmatched = append(unmatched, node)
+	}
+
+	var free []*PathNode
+	for _, node := range GetDfsOrder(currentFileTree) {
+		if !oldFileTree.HasNode(node.Path) {
+			free = append(free, node)
+		}
+	}
+
+	// identical content, folders included since an untouched folder keeps its tree id
+	var remaining []*PathNode
+	for _, node := range unmatched {
+		var candidates []*PathNode
+		for _, current := range free {
+			if !used[current.Path] && node.ObjectID != "" && current.ObjectID == node.ObjectID {
+				candidates = append(candidates, current)
+			}
+		}
+
+		// several copies of the same content, prefer the one that kept its name
+		if len(candidates) > 1 {
+			var sameName []*PathNode
+			for _, current := range candidates {
+				if path.Base(current.Path) == path.Base(node.Path) {
+					sameName = append(sameName, current)
+				}
+			}
+			if len(sameName) == 1 {
+				candidates = sameName
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			remaining = append(remaining, node)
+		case 1:
+			matched[candidates[0].Path] = node
+			used[candidates[0].Path] = true
+			report.ByObject = append(report.ByObject, PathMove{From: node.Path, To: candidates[0].Path, Score: 100})
+		default:
+			report.Ambiguous = append(report.Ambiguous, node.Path)
+		}
+	}
+
+	// similar content, only for files whose old blob is still around
+	unmatched, remaining = remaining, nil
+	for _, node := range unmatched {
+		if node.IsDir || node.Kind == KindSubmodule || node.ObjectID == "" || !objectExists(node.ObjectID) {
+			remaining = append(remaining, node)
+			continue
+		}
+
+		best, bestScore, tied := "", 0, false
+		for _, current := range free {
+			if used[current.Path] || current.IsDir || current.Kind == KindSubmodule {
+				continue
+			}
+			if path.Base(current.Path) != path.Base(node.Path) && parentOf(current.Path) != parentOf(node.Path) {
+				continue
+			}
+
+			change, err := blobChange(node.ObjectID, current.ObjectID)
+			if err != nil {
+				continue
+			}
+			score := 100 - min(change, 100)
+			switch {
+			case score > bestScore:
+				best, bestScore, tied = current.Path, score, false
+			case score == bestScore:
+				tied = true
+			}
+		}
+
+		switch {
+		case best == "" || bestScore < threshold:
+			remaining = append(remaining, node)
+		case tied:
+			report.Ambiguous = append(report.Ambiguous, node.Path)
+		default:
+			matched[best] = node
+			used[best] = true
+			report.BySimilarity = append(report.BySimilarity, PathMove{From: node.Path, To: best, Score: bestScore})
+		}
+	}
+
+	// folders follow the files inside them when those all moved the same way
+	moved := make(map[string]string)
+	for newPath, node := range matched {
+		moved[node.Path] = newPath
+	}
+	for _, node := range remaining {
+		if !node.IsDir {
+			report.Lost = append(report.Lost, node.Path)
+			continue
+		}
+
+		target := ""
+		consistent := true
+		for _, child := range oldFileTree.getSubtree(node.Path) {
+			newPath, ok := moved[child.Path]
+			if child.Path == node.Path || !ok {
+				continue
+			}
+			relative := strings.TrimPrefix(child.Path, node.Path)
+			if !strings.HasSuffix(newPath, relative) {
+				consistent = false
+				break
+			}
+			dir := strings.TrimSuffix(newPath, relative)
+			if target != "" && dir != target {
+				consistent = false
+				break
+			}
+			target = dir
+		}
+
+		current := currentFileTree.GetNode(target)
+		if !consistent || target == "" || used[target] || current == nil || !current.IsDir {
+			report.Lost = append(report.Lost, node.Path)
+			continue
+		}
+		matched[target] = node
+		used[target] = true
+		report.ByFolder = append(report.ByFolder, PathMove{From: node.Path, To: target, Score: 100})
+	}
+	sort.Strings(report.Lost)
+	sort.Strings(report.Ambiguous)
+
+	reconciled := NewFileTree(currentFileTree.CommitHash)
+	reconciled.CopyLayout(oldFileTree)
+	reconciled.Tombstones = oldFileTree.Tombstones
+	reconciled.Pending = oldFileTree.Pending
+
+	// keep what could not be carried over in case it comes back
+	bury := func(p string) {
+		node := oldFileTree.GetNode(p)
+		reconciled.addTombstone(&Tombstone{
+			Path:            node.Path,
+			Description:     node.Description,
+			IsDir:           node.IsDir,
+			ObjectID:        node.ObjectID,
+			DescribedObject: node.DescribedObject,
+			DeletedIn:       currentFileTree.CommitHash,
+		})
+	}
+	for _, p := range report.Lost {
+		bury(p)
+	}
+
+	for _, current := range GetDfsOrder(currentFileTree) {
+		newNode := NewPathNode(current.Path, current.IsDir)
+		if old, ok := matched[current.Path]; ok {
+			copied := *old
+			newNode = &copied
+			newNode.Path = current.Path
+		} else if tombstone := reconciled.restore(newNode, current.ObjectID); tombstone != nil {
+			report.Restored = append(report.Restored, PathMove{From: tombstone.Path, To: current.Path, Score: 100})
+		}
+		newNode.SetObject(current)
+		reconciled.AddNode(newNode)
+	}
+
+	// only now, or restoring by content would pick one of the matches after all
+	for _, p := range report.Ambiguous {
+		bury(p)
+	}
+
+	return reconciled, report
+}
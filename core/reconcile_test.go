@@ -0,0 +1,44 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestReconcileBuriesAmbiguousMatches(t *testing.T) {
+	oldFileTree := NewFileTree("1111111111111111111111111111111111111111")
+	described := NewPathNode("template.txt", false)
+	described.ObjectID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	described.Description = "Template every service starts from"
+	oldFileTree.AddNode(described)
+
+	currentFileTree := NewFileTree("2222222222222222222222222222222222222222")
+	for _, p := range []string{"one.txt", "two.txt"} {
+		node := NewPathNode(p, false)
+		node.ObjectID = described.ObjectID
+		currentFileTree.AddNode(node)
+	}
+
+	reconciled, report := ReconcileFileTree(oldFileTree, currentFileTree, 0)
+	if len(report.Ambiguous) != 1 || report.Ambiguous[0] != "template.txt" {
+		t.Fatalf("Ambiguous = %v, want [template.txt]", report.Ambiguous)
+	}
+	for _, p := range []string{"one.txt", "two.txt"} {
+		if IsDescribed(reconciled.GetNode(p)) {
+			t.Errorf("%s took the description of an ambiguous match", p)
+		}
+	}
+
+	if len(reconciled.Tombstones) != 1 {
+		t.Fatalf("Tombstones = %v, want one for template.txt", reconciled.Tombstones)
+	}
+	tombstone := reconciled.Tombstones[0]
+	if tombstone.Path != "template.txt" || tombstone.Description != described.Description || tombstone.DeletedIn != currentFileTree.CommitHash {
+		t.Errorf("tombstone = %+v, want template.txt with its description, deleted in %s", tombstone, currentFileTree.CommitHash)
+	}
+
+	// the path coming back picks the description up again
+	back := NewPathNode("template.txt", false)
+	if reconciled.restore(back, described.ObjectID) == nil || back.Description != described.Description {
+		t.Errorf("re-adding template.txt did not restore its description")
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+// ChangedTree is the part of a branch that differs from a FileTree: the
+// listings of every folder whose tree id changed, new folders included
+type ChangedTree struct {
+	// Tree holds the entries of the changed folders, with their parents
+	Tree *FileTree
+	// Dirs lists the folders that were read, parents first, "" being the root
+	Dirs []string
+}
+
+// ---------- ReadChangedTree ----------
+// ReadChangedTree walks branch from the root tree down and only lists the
+// folders whose tree id differs from the one ft has recorded, so an unchanged
+// subtree costs nothing no matter how large it is
+func ReadChangedTree(ft *FileTree, branch string) (*ChangedTree, error) {
+	commitHash, err := GetCommitHash(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := &ChangedTree{Tree: NewFileTree(commitHash)}
+
+	var walk func(dir, treeish string) error
+	walk = func(dir, treeish string) error {
+		entries, err := lsTree(treeish)
+		if err != nil {
+			return err
+		}
+		changed.Dirs = append(changed.Dirs, dir)
+
+		for _, entry := range entries {
+			// listing a tree object gives names relative to it
+			entry.Path = joinPath(dir, entry.Path)
+			node := NewPathNodeFromEntry(entry)
+			changed.Tree.AddNode(node)
+
+			if !node.IsDir {
+				continue
+			}
+			if old := ft.GetNode(node.Path); old != nil && old.IsDir && old.ObjectID == node.ObjectID {
+				continue
+			}
+			if err := walk(node.Path, node.ObjectID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk("", commitHash+"^{tree}"); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// ---------- ApplyChangedTree ----------
+// ApplyChangedTree brings the changed folders of ft in line with changed,
+// adding and removing entries and updating object ids while leaving
+// descriptions alone. Removed paths leave tombstones and new ones are matched
+// against them. It returns the nodes that were added or updated along with
+// the tombstones that were restored
+func ApplyChangedTree(ft *FileTree, changed *ChangedTree) ([]*PathNode, []PathMove) {
+	var touched []*PathNode
+	var restored []PathMove
+	commit := changed.Tree.CommitHash
+
+	for _, dir := range changed.Dirs {
+		// drop whatever is no longer in the folder
+		for _, child := range ft.GetChildNodes(dir) {
+			if !changed.Tree.HasNode(child.Path) {
+				ft.bury(child.Path, commit)
+			}
+		}
+
+		for _, current := range changed.Tree.GetChildNodes(dir) {
+			node := ft.GetNode(current.Path)
+			switch {
+			case node == nil:
+				node = NewPathNode(current.Path, current.IsDir)
+				node.SetObject(current)
+				if tombstone := ft.restore(node, current.ObjectID); tombstone != nil {
+					restored = append(restored, PathMove{From: tombstone.Path, To: node.Path, Score: 100})
+				}
+				ft.AddNode(node)
+			case node.ObjectID != current.ObjectID || node.Kind != current.Kind:
+				if node.IsDir && !current.IsDir {
+					// a folder replaced by a file leaves nothing behind below it
+					for _, child := range ft.GetChildNodes(node.Path) {
+						ft.bury(child.Path, commit)
+					}
+				}
+				node.SetObject(current)
+			default:
+				continue
+			}
+			touched = append(touched, node)
+		}
+	}
+
+	ft.CommitHash = commit
+	return touched, restored
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	InjectStartMarker = "<!-- gittier:start -->"
+	InjectEndMarker   = "<!-- gittier:end -->"
+)
+
+// RenderOptions controls which nodes are rendered and how
+type RenderOptions struct {
+	// Style is "tree" for an ASCII tree or "list" for a nested Markdown list
+	Style string
+	// MaxDepth limits how many levels are shown, 0 shows everything
+	MaxDepth int
+	// HideUndescribed drops nodes without a description unless something below them is described
+	HideUndescribed bool
+	// Root renders only the subtree below this path
+	Root string
+	// DirsOnly leaves out files
+	DirsOnly bool
+	// OnlyUndescribed keeps nodes without a description and the folders leading to them
+	OnlyUndescribed bool
+}
+
+// renderNode is a node selected for rendering along with its visible children
+type renderNode struct {
+	node     *PathNode
+	children []*renderNode
+}
+
+// ---------- selectRenderNodes ----------
+// selectRenderNodes walks the tree below parent in GetChildNodes order and
+// applies the depth and description filters
+func selectRenderNodes(ft *FileTree, parent string, depth int, opts RenderOptions) []*renderNode {
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		return nil
+	}
+
+	var result []*renderNode
+	for _, child := range ft.GetChildNodes(parent) {
+		if opts.DirsOnly && !child.IsDir {
+			continue
+		}
+
+		rn := &renderNode{node: child}
+		if child.IsDir {
+			rn.children = selectRenderNodes(ft, child.Path, depth+1, opts)
+		}
+
+		if opts.HideUndescribed && !IsDescribed(child) && len(rn.children) == 0 {
+			continue
+		}
+		if opts.OnlyUndescribed && IsDescribed(child) && len(rn.children) == 0 {
+			continue
+		}
+		result = append(result, rn)
+	}
+	return result
+}
+
+// ---------- selectRenderRoot ----------
+func selectRenderRoot(ft *FileTree, opts RenderOptions) ([]*renderNode, error) {
+	if opts.Root != "" {
+		root := ft.GetNode(opts.Root)
+		if root == nil {
+			return nil, fmt.Errorf("path not found in filetree: %s", opts.Root)
+		}
+		if !root.IsDir {
+			return nil, fmt.Errorf("not a directory: %s", opts.Root)
+		}
+	}
+	return selectRenderNodes(ft, opts.Root, 1, opts), nil
+}
+
+// ---------- RenderMarkdown ----------
+func RenderMarkdown(ft *FileTree, opts RenderOptions) (string, error) {
+	nodes, err := selectRenderRoot(ft, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	switch opts.Style {
+	case "", "tree":
+		b.WriteString("```text\n" + displayPath(opts.Root) + "\n")
+		writeAsciiTree(&b, nodes, "", opts)
+		b.WriteString("```\n")
+	case "list":
+		writeMarkdownList(&b, nodes, 0, opts)
+	default:
+		return "", fmt.Errorf("unknown style %q (expected tree or list)", opts.Style)
+	}
+	return b.String(), nil
+}
+
+// ---------- writeAsciiTree ----------
+func writeAsciiTree(b *strings.Builder, nodes []*renderNode, prefix string, opts RenderOptions) {
+	for i, rn := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		b.WriteString(prefix + branch + displayName(rn.node))
+		if description := renderDescription(rn.node, opts); description != "" {
+			b.WriteString(" — " + description)
+		}
+		b.WriteString("\n")
+
+		writeAsciiTree(b, rn.children, prefix+indent, opts)
+	}
+}
+
+// ---------- writeMarkdownList ----------
+func writeMarkdownList(b *strings.Builder, nodes []*renderNode, level int, opts RenderOptions) {
+	for _, rn := range nodes {
+		name := codeSpan(displayName(rn.node))
+		if rn.node.IsDir {
+			name = "**" + name + "**"
+		}
+
+		b.WriteString(strings.Repeat("  ", level) + "- " + name)
+		if description := renderDescription(rn.node, opts); description != "" {
+			b.WriteString(" — " + markdownEscaper.Replace(description))
+		}
+		b.WriteString("\n")
+
+		writeMarkdownList(b, rn.children, level+1, opts)
+	}
+}
+
+// markdownEscaper escapes the characters that would turn a description into
+// emphasis, a table cell, inline HTML or code in the list style
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"|", `\|`,
+	"<", `\<`,
+)
+
+// ---------- codeSpan ----------
+// codeSpan wraps s in a code span with a fence longer than any run of
+// backticks inside it
+func codeSpan(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+// ---------- displayName ----------
+func displayName(node *PathNode) string {
+	name := path.Base(node.Path)
+	if node.IsDir {
+		name += "/"
+	}
+	return name
+}
+
+// ---------- renderDescription ----------
+// renderDescription flattens a description onto a single line
+func renderDescription(node *PathNode, opts RenderOptions) string {
+	if opts.HideUndescribed && !IsDescribed(node) {
+		return ""
+	}
+	return strings.Join(strings.Fields(node.Description), " ")
+}
+
+// ---------- InjectBetweenMarkers ----------
+// InjectBetweenMarkers replaces whatever sits between InjectStartMarker and
+// InjectEndMarker in content with block, keeping the markers
+func InjectBetweenMarkers(content, block string) (string, error) {
+	start := strings.Index(content, InjectStartMarker)
+	if start < 0 {
+		return "", fmt.Errorf("start marker %s not found", InjectStartMarker)
+	}
+	if strings.Count(content, InjectStartMarker) > 1 || strings.Count(content, InjectEndMarker) > 1 {
+		return "", errors.New("markers appear more than once")
+	}
+
+	end := strings.Index(content, InjectEndMarker)
+	if end < 0 {
+		return "", fmt.Errorf("end marker %s not found", InjectEndMarker)
+	}
+	if end < start {
+		return "", errors.New("end marker comes before start marker")
+	}
+
+	before := content[:start+len(InjectStartMarker)]
+	after := content[end:]
+	return before + "\n" + strings.TrimRight(block, "\n") + "\n" + after, nil
+}
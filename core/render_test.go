@@ -0,0 +1,63 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownListEscapesDescriptions(t *testing.T) {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	described := map[string]string{
+		"cmd":         "parses *flags* | runs `git` <commands>",
+		"cmd/main.go": `snake_case and a \ backslash`,
+		"odd`name":    "plain",
+	}
+	for p, description := range described {
+		node := NewPathNode(p, p == "cmd")
+		node.Description = description
+		fileTree.AddNode(node)
+	}
+
+	out, err := RenderMarkdown(fileTree, RenderOptions{Style: "list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"- **`cmd/`** — parses \\*flags\\* \\| runs \\`git\\` \\<commands>",
+		"  - `main.go` — snake\\_case and a \\\\ backslash",
+		"- `` odd`name `` — plain",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("output is missing %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestRenderMarkdownTreeKeepsDescriptionsVerbatim(t *testing.T) {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	node := NewPathNode("main.go", false)
+	node.Description = "runs *everything* | fast"
+	fileTree.AddNode(node)
+
+	out, err := RenderMarkdown(fileTree, RenderOptions{Style: "tree"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "└── main.go — runs *everything* | fast\n") {
+		t.Errorf("tree output changed the description:\n%s", out)
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	tests := map[string]string{
+		"main.go": "`main.go`",
+		"a`b":     "`` a`b ``",
+		"a``b`":   "``` a``b` ```",
+	}
+	for s, want := range tests {
+		if got := codeSpan(s); got != want {
+			t.Errorf("codeSpan(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
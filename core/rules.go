@@ -0,0 +1,95 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+// ---------- RulesHash ----------
+// RulesHash identifies rules by their patterns, templates and order, "" for none
+func RulesHash(rules []Rule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	hash := sha1.New()
+	for _, rule := range rules {
+		fmt.Fprintf(hash, "%q %q\n", rule.Pattern, rule.Template)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// ---------- SyncRules ----------
+// SyncRules derives descriptions for nodes, or for the whole tree when rules
+// are not the ones the tree was last derived with, and records them. It
+// returns the paths whose description changed
+func (ft *FileTree) SyncRules(rules []Rule, nodes []*PathNode) ([]string, error) {
+	hash := RulesHash(rules)
+	if hash != ft.RulesHash {
+		nodes = ft.getSubtree("")
+	}
+
+	changed, err := ft.DeriveDescriptions(rules, nodes)
+	if err != nil {
+		return changed, err
+	}
+	ft.RulesHash = hash
+	return changed, nil
+}
+
+// ---------- DeriveDescriptions ----------
+// DeriveDescriptions describes every one of nodes nobody described with the
+// first of rules that matches it, and takes back descriptions of rules that
+// no longer match. Descriptions someone wrote are never touched. It returns
+// the paths whose description changed
+func (ft *FileTree) DeriveDescriptions(rules []Rule, nodes []*PathNode) ([]string, error) {
+	var changed []string
+	for _, node := range nodes {
+		if IsDescribed(node) && node.Rule == "" {
+			continue
+		}
+
+		description, pattern := NoDescription, ""
+		if rule, ok := FirstMatchingRule(rules, node); ok {
+			text, err := rule.Describe(node)
+			if err != nil {
+				return changed, err
+			}
+			description, pattern = text, rule.Pattern
+		}
+
+		if node.Description == description && node.Rule == pattern {
+			continue
+		}
+		node.Description = description
+		node.Rule = pattern
+		node.CopiedFrom = ""
+		node.DescribedObject = ""
+		node.Stale = false
+		changed = append(changed, node.Path)
+	}
+	return changed, nil
+}
+
+// ---------- MovedNodes ----------
+// MovedNodes lists the nodes at and below where report moved, copied or
+// restored a description, whose paths changed even when their content did not
+func (ft *FileTree) MovedNodes(report *DiffReport) []*PathNode {
+	var nodes []*PathNode
+	for _, moves := range [][]PathMove{report.DirRenamed, report.Renamed, report.Copied, report.Restored} {
+		for _, move := range moves {
+			nodes = append(nodes, ft.getSubtree(move.To)...)
+		}
+	}
+	return nodes
+}
+
+// ---------- FirstMatchingRule ----------
+func FirstMatchingRule(rules []Rule, node *PathNode) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(node) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,346 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SchemaVersion is the filetree.yaml format written by this version of gittier
+const SchemaVersion = 2
+
+// SchemaURL is the published JSON Schema for the current filetree.yaml format
+const SchemaURL = "https://raw.githubusercontent.com/TyPeterson/Gittier/main/schema/filetree.schema.json"
+
+// yamlFileTree is the on-disk representation of a version 2 filetree.yaml
+type yamlFileTree struct {
+	Version    int          `yaml:"version"`
+	CommitHash string       `yaml:"commit_hash"`
+	ShardDepth int          `yaml:"shard_depth,omitempty"`
+	Tree       []*yamlNode  `yaml:"tree"`
+	Tombstones []*Tombstone `yaml:"tombstones,omitempty"`
+	Pending    []*Pending   `yaml:"pending,omitempty"`
+	Moves      []*Move      `yaml:"moves,omitempty"`
+	RulesHash  string       `yaml:"rules_hash,omitempty"`
+}
+
+// yamlNode stores a single path relative to its parent
+type yamlNode struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	IsDir       bool        `yaml:"is_dir,omitempty"`
+	Kind        string      `yaml:"kind,omitempty"`
+	Object      string      `yaml:"object,omitempty"`
+	CopiedFrom  string      `yaml:"copied_from,omitempty"`
+	Described   string      `yaml:"described_object,omitempty"`
+	Stale       bool        `yaml:"stale,omitempty"`
+	Managed     bool        `yaml:"source_managed,omitempty"`
+	Rule        string      `yaml:"rule,omitempty"`
+	Pack        string      `yaml:"pack,omitempty"`
+	Shard       string      `yaml:"shard,omitempty"`
+	Children    []*yamlNode `yaml:"children,omitempty"`
+}
+
+// fileTreeV1 is the original flat format keyed by full path
+type fileTreeV1 struct {
+	CommitHash string               `yaml:"commit_hash"`
+	Nodes      map[string]*PathNode `yaml:"nodes"`
+}
+
+// ---------- readFileTree ----------
+func readFileTree(metadata metaFS, name string) (*FileTree, error) {
+	data, err := metadata.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading YAML file: %w", err)
+	}
+
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("error unmarshaling YAML: %w", err)
+	}
+
+	switch header.Version {
+	case 0, 1:
+		// files written before versioning have no version key
+		var v1 fileTreeV1
+		if err := yaml.Unmarshal(data, &v1); err != nil {
+			return nil, fmt.Errorf("error unmarshaling YAML: %w", err)
+		}
+		return migrateV1(&v1), nil
+	case SchemaVersion:
+		var v2 yamlFileTree
+		if err := yaml.UnmarshalStrict(data, &v2); err != nil {
+			return nil, fmt.Errorf("error unmarshaling YAML: %w", err)
+		}
+		return readShardedIndex(metadata, &v2)
+	default:
+		return nil, fmt.Errorf("unsupported filetree schema version %d (this gittier supports up to %d)", header.Version, SchemaVersion)
+	}
+}
+
+// ---------- marshalWithSchema ----------
+// marshalWithSchema prefixes the YAML with a modeline pointing editors at the JSON Schema
+func marshalWithSchema(v interface{}) ([]byte, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("# yaml-language-server: $schema=%s\n", SchemaURL)
+	return append([]byte(header), data...), nil
+}
+
+// ---------- migrateV1 ----------
+func migrateV1(v1 *fileTreeV1) *FileTree {
+	fileTree := NewFileTree(v1.CommitHash)
+	for key, node := range v1.Nodes {
+		if node == nil {
+			node = NewPathNode(key, false)
+		}
+		// the map key is authoritative, older files could disagree with the inner path
+		node.Path = key
+		node.SetObject(NewPathNode(key, node.IsDir))
+		fileTree.AddNode(node)
+	}
+
+	// older files often stored folders with is_dir: false or left them out,
+	// whatever has children is a folder
+	var missing []string
+	fileTree.root.walk("", func(p string, t *trieNode) {
+		if p == "" || len(t.children) == 0 {
+			return
+		}
+		if t.node == nil {
+			missing = append(missing, p)
+		} else if !t.node.IsDir {
+			t.node.SetObject(NewPathNode(p, true))
+		}
+	}, nil)
+	for _, p := range missing {
+		fileTree.AddNode(NewPathNode(p, true))
+	}
+	return fileTree
+}
+
+// ---------- fromYamlTree ----------
+func fromYamlTree(yt *yamlFileTree) (*FileTree, error) {
+	fileTree := NewFileTree(yt.CommitHash)
+
+	var problems []error
+	var walk func(parent string, nodes []*yamlNode)
+	walk = func(parent string, nodes []*yamlNode) {
+		for _, yn := range nodes {
+			if yn == nil {
+				continue
+			}
+			if err := validateName(yn.Name); err != nil {
+				problems = append(problems, fmt.Errorf("under '%s': %w", displayPath(parent), err))
+				continue
+			}
+
+			fullPath := joinPath(parent, yn.Name)
+			if fileTree.HasNode(fullPath) {
+				problems = append(problems, fmt.Errorf("duplicate entry: %s", fullPath))
+				continue
+			}
+
+			node, err := nodeFromYaml(fullPath, yn)
+			if err != nil {
+				problems = append(problems, err)
+				continue
+			}
+			fileTree.AddNode(node)
+			walk(fullPath, yn.Children)
+		}
+	}
+	walk("", yt.Tree)
+
+	for _, tombstone := range yt.Tombstones {
+		if tombstone == nil || tombstone.Path == "" {
+			problems = append(problems, errors.New("tombstone without a path"))
+			continue
+		}
+		fileTree.Tombstones = append(fileTree.Tombstones, tombstone)
+	}
+
+	for _, pending := range yt.Pending {
+		if pending == nil || pending.Path == "" {
+			problems = append(problems, errors.New("pending description without a path"))
+			continue
+		}
+		fileTree.Pending = append(fileTree.Pending, pending)
+	}
+
+	fileTree.RulesHash = yt.RulesHash
+
+	for _, move := range yt.Moves {
+		if move == nil || move.From == "" || move.To == "" {
+			problems = append(problems, errors.New("move without a source or destination"))
+			continue
+		}
+		fileTree.Moves = append(fileTree.Moves, move)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid filetree: %w", errors.Join(problems...))
+	}
+	return fileTree, nil
+}
+
+// ---------- nodeFromYaml ----------
+func nodeFromYaml(fullPath string, yn *yamlNode) (*PathNode, error) {
+	isDir := yn.IsDir || len(yn.Children) > 0
+	node := NewPathNode(fullPath, isDir)
+	node.Description = yn.Description
+	node.ObjectID = yn.Object
+	node.CopiedFrom = yn.CopiedFrom
+	node.DescribedObject = yn.Described
+	node.Stale = yn.Stale
+	node.SourceManaged = yn.Managed
+	node.Rule = yn.Rule
+	node.Pack = yn.Pack
+
+	switch yn.Kind {
+	case "", KindFile, KindDir:
+		// implied by is_dir
+	case KindExecutable, KindSymlink, KindSubmodule:
+		if isDir {
+			return nil, fmt.Errorf("%s: a %s cannot be a directory", fullPath, yn.Kind)
+		}
+		node.Kind = yn.Kind
+		node.Mode = modeForKind(yn.Kind)
+	default:
+		return nil, fmt.Errorf("%s: unknown kind %q", fullPath, yn.Kind)
+	}
+
+	return node, nil
+}
+
+// ---------- yamlChildren ----------
+// yamlChildren nests every node below t, t itself is not included. Folders
+// for which cut returns true are written without their children
+func yamlChildren(t *trieNode, cut func(node *PathNode) bool) []*yamlNode {
+	var result []*yamlNode
+	for _, name := range t.sortedNames() {
+		childTrie := t.children[name]
+		child := childTrie.node
+		if child == nil {
+			continue
+		}
+
+		yn := &yamlNode{
+			Name:        name,
+			Description: child.Description,
+			IsDir:       child.IsDir,
+			Object:      child.ObjectID,
+			CopiedFrom:  child.CopiedFrom,
+			Described:   child.DescribedObject,
+			Stale:       child.Stale,
+			Managed:     child.SourceManaged,
+			Rule:        child.Rule,
+			Pack:        child.Pack,
+		}
+		if cut == nil || !cut(child) {
+			yn.Children = yamlChildren(childTrie, cut)
+		}
+		// plain files and folders are implied by is_dir
+		if child.Kind != KindFile && child.Kind != KindDir {
+			yn.Kind = child.Kind
+		}
+		result = append(result, yn)
+	}
+	return result
+}
+
+// ---------- ValidateFileTree ----------
+// ValidateFileTree checks that every node in memory has a parent directory
+// and that node paths agree with their place in the tree
+func ValidateFileTree(ft *FileTree) error {
+	var problems []error
+
+	if ft.CommitHash == "" {
+		problems = append(problems, errors.New("missing commit_hash"))
+	}
+
+	ft.root.walk("", func(p string, t *trieNode) {
+		// empty segments are left by children of missing folders, which report them
+		if p == "" || t.node == nil {
+			return
+		}
+		if t.node.Path != p {
+			problems = append(problems, fmt.Errorf("node path %s does not match its place %s", t.node.Path, p))
+		}
+
+		for _, segment := range strings.Split(p, "/") {
+			if err := validateName(segment); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", p, err))
+				break
+			}
+		}
+
+		parent := parentOf(p)
+		if parent == "" {
+			return
+		}
+		parentNode := ft.find(parent).node
+		if parentNode == nil {
+			problems = append(problems, fmt.Errorf("%s: parent directory %s is missing", p, parent))
+		} else if !parentNode.IsDir {
+			problems = append(problems, fmt.Errorf("%s: parent %s is not a directory", p, parent))
+		}
+	}, nil)
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// sort for stable output
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Error() < problems[j].Error()
+	})
+	return fmt.Errorf("invalid filetree: %w", errors.Join(problems...))
+}
+
+// ---------- validateName ----------
+func validateName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("empty path segment")
+	case name == "." || name == "..":
+		return fmt.Errorf("relative path segment %q", name)
+	case strings.Contains(name, "/"):
+		return fmt.Errorf("name %q must not contain '/'", name)
+	}
+	return nil
+}
+
+// ---------- parentOf ----------
+// parentOf returns the parent of a slash separated path, or "" for top-level paths
+func parentOf(p string) string {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return ""
+	}
+	return p[:i]
+}
+
+// ---------- joinPath ----------
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// ---------- displayPath ----------
+func displayPath(p string) string {
+	if p == "" {
+		return "."
+	}
+	return p
+}
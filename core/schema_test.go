@@ -0,0 +1,67 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadV1FileTreeFixesFolders(t *testing.T) {
+	// written by releases before folders were flagged reliably
+	v1 := `commit_hash: 0123456789abcdef0123456789abcdef01234567
+nodes:
+  cmd:
+    path: cmd
+    description: Commands
+    is_dir: false
+  cmd/sub/b.txt:
+    path: cmd/sub/b.txt
+    description: no description added
+    is_dir: false
+  cmd/a.go:
+    path: cmd/old.go
+    description: First command
+    is_dir: false
+  main.go:
+    path: main.go
+    description: Entry point
+    is_dir: false
+`
+	filename := filepath.Join(t.TempDir(), "filetree.yaml")
+	if err := os.WriteFile(filename, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fileTree, err := ReadFileTreeFromYaml(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path        string
+		isDir       bool
+		description string
+	}{
+		{"cmd", true, "Commands"},
+		{"cmd/sub", true, NoDescription},
+		{"cmd/sub/b.txt", false, NoDescription},
+		{"cmd/a.go", false, "First command"},
+		{"main.go", false, "Entry point"},
+	}
+	for _, test := range tests {
+		node := fileTree.GetNode(test.path)
+		if node == nil {
+			t.Errorf("%s is missing", test.path)
+			continue
+		}
+		if node.IsDir != test.isDir || node.Description != test.description || node.Path != test.path {
+			t.Errorf("%s = {path %s, dir %t, %q}, want {dir %t, %q}", test.path, node.Path, node.IsDir, node.Description, test.isDir, test.description)
+		}
+		if test.isDir && node.Kind != KindDir {
+			t.Errorf("%s has kind %s, want %s", test.path, node.Kind, KindDir)
+		}
+	}
+	if fileTree.Len() != len(tests) {
+		t.Errorf("Len() = %d, want %d", fileTree.Len(), len(tests))
+	}
+}
@@ -0,0 +1,339 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// yamlShard holds every node below a single directory when the tree is sharded
+type yamlShard struct {
+	Version int         `yaml:"version"`
+	Path    string      `yaml:"path"`
+	Tree    []*yamlNode `yaml:"tree"`
+}
+
+// metaFS is where metadata files are read from and written to
+type metaFS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte) error
+	Remove(name string) error
+}
+
+// dirFS is a metaFS rooted at a directory on disk
+type dirFS string
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d dirFS) WriteFile(name string, data []byte) error {
+	fullPath := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, data, 0644)
+}
+
+func (d dirFS) Remove(name string) error {
+	fullPath := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// clean up directories left empty inside the shard folder
+	for dir := filepath.Dir(fullPath); dir != string(d) && dir != "."; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// shardStore tracks which shards of a FileTree have been read so far
+type shardStore struct {
+	fs     metaFS
+	files  map[string]string // shard root -> shard file
+	loaded map[string]bool
+}
+
+// ---------- ShardDir ----------
+// ShardDir returns the folder holding the shard files for an index file
+func ShardDir(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".d"
+}
+
+// ---------- shardFileName ----------
+func shardFileName(index, root string) string {
+	return path.Join(ShardDir(index), root+".yaml")
+}
+
+// ---------- pathDepth ----------
+func pathDepth(p string) int {
+	return strings.Count(p, "/") + 1
+}
+
+// ---------- isShardRoot ----------
+func (ft *FileTree) isShardRoot(node *PathNode) bool {
+	return ft.ShardDepth > 0 && node.IsDir && pathDepth(node.Path) == ft.ShardDepth
+}
+
+// ---------- shardRoots ----------
+// shardRoots lists the folders at ShardDepth, which each get a shard file
+func (ft *FileTree) shardRoots() []string {
+	var roots []string
+	if ft.ShardDepth <= 0 {
+		return roots
+	}
+
+	var collect func(parent string, t *trieNode, depth int)
+	collect = func(parent string, t *trieNode, depth int) {
+		for _, name := range t.sortedNames() {
+			child := t.children[name]
+			fullPath := joinPath(parent, name)
+			if depth < ft.ShardDepth {
+				collect(fullPath, child, depth+1)
+			} else if child.node != nil && ft.isShardRoot(child.node) {
+				roots = append(roots, fullPath)
+			}
+		}
+	}
+	collect("", ft.root, 1)
+	return roots
+}
+
+// ---------- loadShard ----------
+func (ft *FileTree) loadShard(root string) error {
+	store := ft.shards
+	file := store.files[root]
+
+	data, err := store.fs.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading shard %s: %w", file, err)
+	}
+
+	var shard yamlShard
+	if err := yaml.UnmarshalStrict(data, &shard); err != nil {
+		return fmt.Errorf("error unmarshaling shard %s: %w", file, err)
+	}
+	if shard.Path != root {
+		return fmt.Errorf("shard %s describes %s, expected %s", file, shard.Path, root)
+	}
+
+	// mark loaded first so that adding nodes does not recurse into this shard
+	store.loaded[root] = true
+
+	partial, err := fromYamlTree(&yamlFileTree{Tree: shard.Tree})
+	if err != nil {
+		return fmt.Errorf("shard %s: %w", file, err)
+	}
+	for name, child := range partial.root.children {
+		ft.attach(joinPath(root, name), child)
+	}
+
+	return nil
+}
+
+// ---------- loadShards ----------
+// loadShards reads every pending shard for which match returns true
+func (ft *FileTree) loadShards(match func(root string) bool) {
+	if ft.shards == nil {
+		return
+	}
+
+	var roots []string
+	for root := range ft.shards.files {
+		if !ft.shards.loaded[root] && match(root) {
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+
+	for _, root := range roots {
+		if err := ft.loadShard(root); err != nil {
+			// the shard stays pending so the write path refuses to drop it
+			ft.shards.loaded[root] = false
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+}
+
+// ---------- loadPath ----------
+// loadPath makes sure the node at path is in memory
+func (ft *FileTree) loadPath(p string) {
+	ft.loadShards(func(root string) bool {
+		return strings.HasPrefix(p, root+"/")
+	})
+}
+
+// ---------- loadSubtree ----------
+// loadSubtree makes sure path and everything below it is in memory
+func (ft *FileTree) loadSubtree(p string) {
+	ft.loadShards(func(root string) bool {
+		return p == "" || root == p || strings.HasPrefix(root, p+"/") || strings.HasPrefix(p, root+"/")
+	})
+}
+
+// ---------- loadAll ----------
+func (ft *FileTree) loadAll() {
+	ft.loadShards(func(string) bool { return true })
+}
+
+// ---------- pendingShards ----------
+func (ft *FileTree) pendingShards() map[string]string {
+	pending := make(map[string]string)
+	if ft.shards == nil {
+		return pending
+	}
+	for root, file := range ft.shards.files {
+		if !ft.shards.loaded[root] {
+			pending[root] = file
+		}
+	}
+	return pending
+}
+
+// ---------- SetShardDepth ----------
+// SetShardDepth changes the layout used the next time the tree is written,
+// a depth of 0 stores everything in a single file
+func (ft *FileTree) SetShardDepth(depth int) error {
+	if depth < 0 {
+		return fmt.Errorf("invalid shard depth: %d", depth)
+	}
+
+	ft.loadAll()
+	if len(ft.pendingShards()) > 0 {
+		return errors.New("cannot change shard depth while shards failed to load")
+	}
+
+	ft.ShardDepth = depth
+	return nil
+}
+
+// ---------- readShardedIndex ----------
+func readShardedIndex(metadata metaFS, yt *yamlFileTree) (*FileTree, error) {
+	fileTree, err := fromYamlTree(yt)
+	if err != nil {
+		return nil, err
+	}
+	fileTree.ShardDepth = yt.ShardDepth
+	fileTree.shards = &shardStore{
+		fs:     metadata,
+		files:  make(map[string]string),
+		loaded: make(map[string]bool),
+	}
+
+	var collect func(parent string, nodes []*yamlNode)
+	collect = func(parent string, nodes []*yamlNode) {
+		for _, yn := range nodes {
+			fullPath := joinPath(parent, yn.Name)
+			if yn.Shard != "" {
+				fileTree.shards.files[fullPath] = yn.Shard
+			}
+			collect(fullPath, yn.Children)
+		}
+	}
+	collect("", yt.Tree)
+
+	return fileTree, nil
+}
+
+// ---------- writeFileTree ----------
+// writeFileTree writes the index and every loaded shard, shards that were
+// never read are left untouched
+func writeFileTree(metadata metaFS, index string, ft *FileTree) error {
+	pending := ft.pendingShards()
+
+	// shard roots are written without their children, which live in the shard
+	yt := &yamlFileTree{
+		Version:    SchemaVersion,
+		CommitHash: ft.CommitHash,
+		ShardDepth: ft.ShardDepth,
+		Tree:       yamlChildren(ft.root, ft.isShardRoot),
+		Tombstones: ft.Tombstones,
+		Pending:    ft.Pending,
+		Moves:      ft.Moves,
+		RulesHash:  ft.RulesHash,
+	}
+
+	// link every shard root in the index to its shard file
+	linked := make(map[string]bool)
+	var link func(parent string, nodes []*yamlNode)
+	link = func(parent string, nodes []*yamlNode) {
+		for _, yn := range nodes {
+			fullPath := joinPath(parent, yn.Name)
+			if file, ok := pending[fullPath]; ok {
+				yn.Shard = file
+			} else if t := ft.find(fullPath); t != nil && t.node != nil && ft.isShardRoot(t.node) {
+				yn.Shard = shardFileName(index, fullPath)
+			}
+			if yn.Shard != "" {
+				linked[yn.Shard] = true
+			}
+			link(fullPath, yn.Children)
+		}
+	}
+	link("", yt.Tree)
+
+	for _, root := range ft.shardRoots() {
+		if _, ok := pending[root]; ok {
+			continue
+		}
+
+		shard := &yamlShard{
+			Version: SchemaVersion,
+			Path:    root,
+			Tree:    yamlChildren(ft.find(root), nil),
+		}
+		data, err := marshalWithSchema(shard)
+		if err != nil {
+			return fmt.Errorf("error marshaling shard %s: %w", root, err)
+		}
+		if err := writeIfChanged(metadata, shardFileName(index, root), data); err != nil {
+			return err
+		}
+	}
+
+	data, err := marshalWithSchema(yt)
+	if err != nil {
+		return fmt.Errorf("error marshaling YAML: %w", err)
+	}
+	if err := writeIfChanged(metadata, index, data); err != nil {
+		return err
+	}
+
+	// remove shards that no longer back any directory
+	if ft.shards != nil {
+		for _, file := range ft.shards.files {
+			if !linked[file] {
+				if err := metadata.Remove(file); err != nil {
+					return fmt.Errorf("error removing shard %s: %w", file, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ---------- writeIfChanged ----------
+// writeIfChanged skips the write when the file already holds data, so
+// untouched shards keep their timestamps and never show up in a diff
+func writeIfChanged(metadata metaFS, name string, data []byte) error {
+	if existing, err := metadata.ReadFile(name); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	if err := metadata.WriteFile(name, data); err != nil {
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DefaultStaleThreshold is how much in percent a path may change after it was
+// described before sync flags the description as possibly stale
+const DefaultStaleThreshold = 50
+
+// ---------- FlagStaleNodes ----------
+// FlagStaleNodes compares each described node with the object it was
+// described against and flags it once the content moved on by at least
+// threshold percent, returning the newly flagged paths
+func FlagStaleNodes(nodes []*PathNode, threshold int) ([]string, error) {
+	if threshold == 0 {
+		threshold = DefaultStaleThreshold
+	}
+
+	var flagged []string
+	for _, node := range nodes {
+		if node.Stale || !IsDescribed(node) || node.Rule != "" || node.DescribedObject == "" || node.ObjectID == "" || node.DescribedObject == node.ObjectID {
+			continue
+		}
+
+		changed, err := objectChange(node)
+		if err != nil {
+			return flagged, fmt.Errorf("failed to compare %s: %w", node.Path, err)
+		}
+		if changed >= threshold {
+			node.Stale = true
+			flagged = append(flagged, node.Path)
+		}
+	}
+	return flagged, nil
+}
+
+// ---------- objectChange ----------
+// objectChange estimates in percent how far a node's object moved on from the
+// one it was described against
+func objectChange(node *PathNode) (int, error) {
+	// the old object went away with rewritten history, nothing left to compare
+	if !objectExists(node.DescribedObject) {
+		return 100, nil
+	}
+
+	switch node.Kind {
+	case KindDir:
+		return treeChange(node.DescribedObject, node.ObjectID)
+	case KindSubmodule:
+		// a submodule moving to a newer commit says nothing about what it is for
+		return 0, nil
+	default:
+		return blobChange(node.DescribedObject, node.ObjectID)
+	}
+}
+
+// ---------- blobChange ----------
+// blobChange is the larger of the share of lines added or removed and the
+// change in size, both relative to the old blob
+func blobChange(oldBlob, newBlob string) (int, error) {
+	oldSize, err := objectSize(oldBlob)
+	if err != nil {
+		return 0, err
+	}
+	newSize, err := objectSize(newBlob)
+	if err != nil {
+		return 0, err
+	}
+	change := percentOf(absInt(newSize-oldSize), oldSize)
+
+	oldLines, err := blobLines(oldBlob)
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command("git", "diff", "--numstat", oldBlob, newBlob)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+
+	// binary files show "-" for both counts, the size change is all there is
+	fields := strings.Fields(string(output))
+	if len(fields) >= 2 && fields[0] != "-" {
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		change = max(change, percentOf(added+removed, oldLines))
+	}
+	return change, nil
+}
+
+// ---------- treeChange ----------
+// treeChange is the share of entries added or removed directly inside a
+// folder. A new subfolder always counts as a full change
+func treeChange(oldTree, newTree string) (int, error) {
+	oldEntries, err := lsTree(oldTree)
+	if err != nil {
+		return 0, err
+	}
+	newEntries, err := lsTree(newTree)
+	if err != nil {
+		return 0, err
+	}
+
+	before := make(map[string]bool)
+	for _, entry := range oldEntries {
+		before[entry.Path] = true
+	}
+
+	changed := 0
+	after := make(map[string]bool)
+	for _, entry := range newEntries {
+		after[entry.Path] = true
+		if before[entry.Path] {
+			continue
+		}
+		if entry.Type == "tree" {
+			return 100, nil
+		}
+		changed++
+	}
+	for name := range before {
+		if !after[name] {
+			changed++
+		}
+	}
+	return percentOf(changed, len(oldEntries)), nil
+}
+
+// ---------- objectSize ----------
+func objectSize(object string) (int, error) {
+	cmd := exec.Command("git", "cat-file", "-s", object)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("object %s not found", object)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// ---------- blobLines ----------
+func blobLines(blob string) (int, error) {
+	cmd := exec.Command("git", "cat-file", "blob", blob)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("blob %s not found", blob)
+	}
+	return strings.Count(string(output), "\n"), nil
+}
+
+// ---------- percentOf ----------
+func percentOf(part, whole int) int {
+	if whole <= 0 {
+		whole = 1
+	}
+	return part * 100 / whole
+}
+
+// ---------- absInt ----------
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -3,140 +3,200 @@ package core
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v2"
 )
 
 // ---------- AddNode ----------
 func (ft *FileTree) AddNode(node *PathNode) {
-	ft.Nodes[node.Path] = node
+	ft.insert(node)
 }
 
 // ---------- GetNode ----------
 func (ft *FileTree) GetNode(path string) *PathNode {
-	return ft.Nodes[path]
+	ft.loadPath(path)
+	if t := ft.find(path); t != nil {
+		return t.node
+	}
+	return nil
 }
 
 // ---------- DeleteNode ----------
+// DeleteNode removes path and everything below it
 func (ft *FileTree) DeleteNode(path string) error {
-	if _, exists := ft.Nodes[path]; !exists {
+	ft.loadSubtree(path)
+	if t := ft.find(path); t == nil || t.node == nil {
 		return fmt.Errorf("node does not exist: %s", path)
 	}
 
-	for nodePath := range ft.Nodes {
-		if strings.HasPrefix(nodePath, path) {
-			delete(ft.Nodes, nodePath)
-		}
-	}
-
+	ft.detach(path)
 	return nil
 }
 
 // ---------- UpdateNodePath ----------
+// UpdateNodePath moves oldPath and everything below it to newPath
 func (ft *FileTree) UpdateNodePath(oldPath, newPath string) error {
-	node, exists := ft.Nodes[oldPath]
-	if !exists {
+	ft.loadSubtree(oldPath)
+	ft.loadSubtree(newPath)
+	if t := ft.find(oldPath); t == nil || t.node == nil {
 		return fmt.Errorf("node does not exist: %s", oldPath)
 	}
-
-	// update nodes as well as any children
-	nodesToUpdate := make(map[string]*PathNode)
-	for path, n := range ft.Nodes {
-		if strings.HasPrefix(path, oldPath) {
-			updatedPath := strings.Replace(path, oldPath, newPath, 1)
-			n.Path = updatedPath
-			nodesToUpdate[updatedPath] = n
-			delete(ft.Nodes, path)
-		}
+	if oldPath == newPath || ft.IsAncestor(oldPath, newPath) {
+		return fmt.Errorf("cannot move %s into itself", oldPath)
 	}
 
-	// update the node within FileTree
-	for path, n := range nodesToUpdate {
-		ft.Nodes[path] = n
+	ft.attach(newPath, ft.detach(oldPath))
+	return nil
+}
+
+// ---------- getSubtree ----------
+// getSubtree returns the node at p and every node below it
+func (ft *FileTree) getSubtree(p string) []*PathNode {
+	ft.loadSubtree(p)
+	t := ft.find(p)
+	if t == nil {
+		return nil
 	}
 
-	node.Path = newPath
+	var nodes []*PathNode
+	t.walk(p, func(_ string, t *trieNode) {
+		if t.node != nil {
+			nodes = append(nodes, t.node)
+		}
+	}, nil)
+	return nodes
+}
 
-	return nil
+// ---------- takeSubtree ----------
+// takeSubtree removes the node at p and everything below it, returning the
+// removed part for putSubtree
+func (ft *FileTree) takeSubtree(p string) *trieNode {
+	ft.loadSubtree(p)
+	return ft.detach(p)
+}
+
+// ---------- putSubtree ----------
+// putSubtree inserts a subtree returned by takeSubtree at its new path p
+func (ft *FileTree) putSubtree(p string, t *trieNode) {
+	if t == nil {
+		return
+	}
+	ft.loadSubtree(p)
+	ft.attach(p, t)
 }
 
 // ---------- UpdateNodeDescription ----------
 func (ft *FileTree) UpdateNodeDescription(path, description string) error {
-	node, exists := ft.Nodes[path]
-	if !exists {
+	node := ft.GetNode(path)
+	if node == nil {
 		return fmt.Errorf("node does not exist: %s", path)
 	}
 
-	node.Description = description
+	node.SetDescription(description)
 	return nil
 }
 
 // ---------- HasNode ----------
 func (ft *FileTree) HasNode(path string) bool {
-	_, exists := ft.Nodes[path]
-	return exists
+	return ft.GetNode(path) != nil
+}
+
+// ---------- Len ----------
+// Len returns the number of nodes in the tree
+func (ft *FileTree) Len() int {
+	ft.loadAll()
+	return ft.size
 }
 
 // ---------- Clone ----------
 func (ft *FileTree) Clone() *FileTree {
+	ft.loadAll()
+
 	newTree := NewFileTree(ft.CommitHash)
-	for path, node := range ft.Nodes {
-		newNode := &PathNode{
-			Path:        node.Path,
-			Description: node.Description,
-			IsDir:       node.IsDir,
-		}
-		newTree.Nodes[path] = newNode
+	newTree.CopyLayout(ft)
+	newTree.root = ft.root.clone()
+	newTree.size = ft.size
+	newTree.RulesHash = ft.RulesHash
+	for _, tombstone := range ft.Tombstones {
+		copied := *tombstone
+		newTree.Tombstones = append(newTree.Tombstones, &copied)
+	}
+	for _, pending := range ft.Pending {
+		copied := *pending
+		newTree.Pending = append(newTree.Pending, &copied)
+	}
+	for _, move := range ft.Moves {
+		copied := *move
+		newTree.Moves = append(newTree.Moves, &copied)
 	}
 	return newTree
 }
 
 // ---------- GetChildNodes ----------
+// GetChildNodes returns the direct children of path in name order
 func (ft *FileTree) GetChildNodes(path string) []*PathNode {
+	ft.loadSubtree(path)
+	t := ft.find(path)
+	if t == nil {
+		return nil
+	}
+
 	var children []*PathNode
-	for nodePath, node := range ft.Nodes {
-		parentPath := getParentPath(nodePath)
-		if parentPath == path {
-			children = append(children, node)
+	for _, name := range t.sortedNames() {
+		if child := t.children[name].node; child != nil {
+			children = append(children, child)
 		}
 	}
 	return children
 }
 
+// ---------- CopyLayout ----------
+// CopyLayout keeps the on-disk layout of other when ft replaces it
+func (ft *FileTree) CopyLayout(other *FileTree) {
+	ft.ShardDepth = other.ShardDepth
+	ft.shards = other.shards
+}
+
 // ---------- IsAncestor ----------
 func (ft *FileTree) IsAncestor(potentialAncestor, path string) bool {
 	return strings.HasPrefix(path, potentialAncestor+"/")
 }
 
 // ---------- ReadFileTreeFromYaml ----------
+// ReadFileTreeFromYaml reads filename and, for sharded trees, defers reading
+// each shard until a node inside it is first accessed
 func ReadFileTreeFromYaml(filename string) (*FileTree, error) {
-	data, err := os.ReadFile(filename)
+	// decode either the flat v1 layout or the nested v2 layout into a FileTree
+	fileTree, err := readFileTree(dirFS(filepath.Dir(filename)), filepath.Base(filename))
 	if err != nil {
-		return nil, fmt.Errorf("error reading YAML file: %w", err)
+		return nil, err
 	}
 
-	// unmarshal the yaml directly into a FileTree
-	var fileTree FileTree
-	err = yaml.Unmarshal(data, &fileTree)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling YAML: %w", err)
+	if err := ValidateFileTree(fileTree); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
 	}
 
-	return &fileTree, nil
+	return fileTree, nil
 }
 
 // ---------- WriteFileTreeToYaml ----------
+// WriteFileTreeToYaml only rewrites the files whose content changed
 func WriteFileTreeToYaml(ft *FileTree, filename string) error {
-	data, err := yaml.Marshal(ft)
-	if err != nil {
-		return fmt.Errorf("error marshaling YAML: %w", err)
+	if err := ValidateFileTree(ft); err != nil {
+		return err
 	}
 
-	err = os.WriteFile(filename, data, 0644)
-	if err != nil {
-		return fmt.Errorf("error writing YAML file: %w", err)
-	}
+	return writeFileTree(dirFS(filepath.Dir(filename)), filepath.Base(filename), ft)
+}
 
-	return nil
+// ---------- MetadataPaths ----------
+// MetadataPaths lists everything that has to be staged after writing filename
+func MetadataPaths(filename string) []string {
+	paths := []string{filename}
+
+	shardDir := filepath.Join(filepath.Dir(filename), ShardDir(filename))
+	if _, err := os.Stat(shardDir); err == nil || IsTracked(shardDir) {
+		paths = append(paths, shardDir)
+	}
+	return paths
 }
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile is read from the main branch and selects how metadata is stored
+const ConfigFile = ".gittier/config.yaml"
+
+// MetaRef is the default ref used by the "ref" backend
+const MetaRef = "refs/gittier/meta"
+
+// NotesRef is the notes ref used by the "notes" backend
+const NotesRef = "refs/notes/gittier"
+
+// Store loads and saves the description metadata of a repository
+type Store interface {
+	// Name describes where the metadata lives, for messages
+	Name() string
+	// Exists reports whether metadata has been saved before
+	Exists() bool
+	Load() (*FileTree, error)
+	Save(ft *FileTree, message string) error
+	// Remove deletes all stored metadata
+	Remove() error
+}
+
+type Config struct {
+	// Backend is one of "branch", "ref", "notes" or "main"
+	Backend string `yaml:"backend"`
+	// Ref overrides the ref used by the "ref" backend
+	Ref string `yaml:"ref,omitempty"`
+	// GenerateReadmes makes commit write a README.md into every described
+	// directory on the showcase branch
+	GenerateReadmes bool `yaml:"generate_readmes,omitempty"`
+	// RenameThreshold is how similar in percent a file must stay for sync to
+	// treat it as renamed or copied, 0 uses git's default of 50
+	RenameThreshold int `yaml:"rename_threshold,omitempty"`
+	// FindCopiesHarder makes sync treat a new file as a copy of any similar
+	// file on main, not only of one changed in the same sync, so the copy
+	// takes the description of a file nobody touched
+	FindCopiesHarder bool `yaml:"find_copies_harder,omitempty"`
+	// StaleThreshold is how much in percent a path may change after it was
+	// described before sync flags the description, 0 uses DefaultStaleThreshold
+	StaleThreshold int `yaml:"stale_threshold,omitempty"`
+	// PendingMaxDays is how many days a pending description may wait for its
+	// path before status warns about it, 0 uses DefaultPendingMaxAge
+	PendingMaxDays int `yaml:"pending_max_days,omitempty"`
+	// Rules map glob patterns to description templates for paths nobody
+	// described, the first matching rule wins
+	Rules yaml.MapSlice `yaml:"rules,omitempty"`
+
+	// rules holds Rules once they have been checked
+	rules []Rule
+}
+
+// ---------- DefaultConfig ----------
+func DefaultConfig() *Config {
+	return &Config{Backend: "branch"}
+}
+
+// ---------- LoadConfig ----------
+// LoadConfig reads ConfigFile from main, falling back to the defaults when
+// the repository has not committed one. Any other failure is returned, so a
+// missing main or a broken repository does not quietly pick a backend
+func LoadConfig() (*Config, error) {
+	config := DefaultConfig()
+
+	lsCmd := exec.Command("git", "ls-tree", "--name-only", "refs/heads/main", "--", ConfigFile)
+	listed, err := lsCmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look for %s on main: %w\n%s", ConfigFile, err, strings.TrimSpace(string(listed)))
+	}
+	if len(listed) == 0 {
+		return config, nil
+	}
+
+	output, err := exec.Command("git", "show", "refs/heads/main:"+ConfigFile).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from main: %w", ConfigFile, err)
+	}
+
+	if err := yaml.UnmarshalStrict(output, config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", ConfigFile, err)
+	}
+	if config.RenameThreshold < 0 || config.RenameThreshold > 100 {
+		return nil, fmt.Errorf("%s: rename_threshold must be between 1 and 100", ConfigFile)
+	}
+	if config.StaleThreshold < 0 {
+		return nil, fmt.Errorf("%s: stale_threshold cannot be negative", ConfigFile)
+	}
+	if config.PendingMaxDays < 0 {
+		return nil, fmt.Errorf("%s: pending_max_days cannot be negative", ConfigFile)
+	}
+	if config.rules, err = parseRules(config.Rules); err != nil {
+		return nil, fmt.Errorf("%s: rules: %w", ConfigFile, err)
+	}
+	return config, nil
+}
+
+// ---------- DescriptionRules ----------
+func (config *Config) DescriptionRules() []Rule {
+	return config.rules
+}
+
+// ---------- PendingMaxAge ----------
+func (config *Config) PendingMaxAge() time.Duration {
+	return time.Duration(config.PendingMaxDays) * 24 * time.Hour
+}
+
+// ---------- OpenStore ----------
+func OpenStore(config *Config) (Store, error) {
+	switch strings.ToLower(config.Backend) {
+	case "", "branch":
+		return newGitRefStore("refs/heads/"+FileTreeBranch, true), nil
+	case "ref":
+		ref := config.Ref
+		if ref == "" {
+			ref = MetaRef
+		}
+		if !strings.HasPrefix(ref, "refs/") {
+			return nil, fmt.Errorf("ref backend needs a full ref name, got %s", ref)
+		}
+		return newGitRefStore(ref, false), nil
+	case "notes":
+		return &notesStore{ref: NotesRef}, nil
+	case "main":
+		return &worktreeStore{dir: ".gittier"}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected branch, ref, notes or main)", config.Backend)
+	}
+}
+
+// ---------- OpenConfiguredStore ----------
+func OpenConfiguredStore() (Store, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return OpenStore(config)
+}
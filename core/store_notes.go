@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// notesStore attaches filetree.yaml as a git note to the commit on main it
+// was synced against, so the metadata travels with the source history
+type notesStore struct {
+	ref string
+}
+
+func (s *notesStore) Name() string {
+	return fmt.Sprintf("git notes in %s", s.ref)
+}
+
+func (s *notesStore) Exists() bool {
+	_, _, err := s.latestNote()
+	return err == nil
+}
+
+func (s *notesStore) Load() (*FileTree, error) {
+	commit, blob, err := s.latestNote()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := catBlob(blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note on %s: %w", commit, err)
+	}
+
+	fileTree, err := readFileTree(&noteFS{data: data}, "filetree.yaml")
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFileTree(fileTree); err != nil {
+		return nil, fmt.Errorf("note on %s: %w", commit, err)
+	}
+	return fileTree, nil
+}
+
+func (s *notesStore) Save(ft *FileTree, message string) error {
+	if ft.ShardDepth > 0 {
+		return errors.New("the notes backend stores a single note per commit and cannot be sharded")
+	}
+	if err := ValidateFileTree(ft); err != nil {
+		return err
+	}
+
+	noteFS := &noteFS{}
+	if err := writeFileTree(noteFS, "filetree.yaml", ft); err != nil {
+		return err
+	}
+
+	blob, err := hashObject(noteFS.data)
+	if err != nil {
+		return err
+	}
+
+	// -C reuses the blob as is, -m would reformat the YAML
+	cmd := exec.Command("git", "notes", "--ref", s.ref, "add", "-f", "-C", blob, ft.CommitHash)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to add note to %s: %w\n%s", ft.CommitHash, err, string(output))
+	}
+	return nil
+}
+
+func (s *notesStore) Remove() error {
+	if _, err := resolveRef(s.ref); err != nil {
+		return nil
+	}
+	cmd := exec.Command("git", "update-ref", "-d", s.ref)
+	return cmd.Run()
+}
+
+// ---------- latestNote ----------
+// latestNote finds the most recent commit on main carrying a note
+func (s *notesStore) latestNote() (string, string, error) {
+	cmd := exec.Command("git", "notes", "--ref", s.ref, "list")
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return "", "", fmt.Errorf("no metadata found in %s, run 'gittier init' first", s.ref)
+	}
+
+	// each line is "<note blob> <annotated commit>"
+	notes := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			notes[fields[1]] = fields[0]
+		}
+	}
+
+	revList := exec.Command("git", "rev-list", "refs/heads/main")
+	stdout, err := revList.StdoutPipe()
+	if err != nil {
+		return "", "", err
+	}
+	if err := revList.Start(); err != nil {
+		return "", "", err
+	}
+	defer revList.Wait()
+	defer stdout.Close()
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		commit := scanner.Text()
+		if blob, ok := notes[commit]; ok {
+			return commit, blob, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("no commit on main has a note in %s", s.ref)
+}
+
+// noteFS holds the single file a note can carry
+type noteFS struct {
+	data []byte
+}
+
+func (n *noteFS) ReadFile(name string) ([]byte, error) {
+	if name != "filetree.yaml" || n.data == nil {
+		return nil, os.ErrNotExist
+	}
+	return n.data, nil
+}
+
+func (n *noteFS) WriteFile(name string, data []byte) error {
+	if name != "filetree.yaml" {
+		return fmt.Errorf("the notes backend cannot store %s", name)
+	}
+	n.data = data
+	return nil
+}
+
+func (n *noteFS) Remove(name string) error {
+	return nil
+}
+
+// ---------- catBlob ----------
+func catBlob(object string) ([]byte, error) {
+	cmd := exec.Command("git", "cat-file", "blob", object)
+	return cmd.Output()
+}
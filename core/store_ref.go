@@ -0,0 +1,239 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gitRefStore keeps filetree.yaml in the tree of a commit that a ref points at,
+// either the showcase branch itself or a dedicated ref outside refs/heads
+type gitRefStore struct {
+	ref string
+	// showcase is true when the ref is the branch commit shows descriptions on
+	showcase bool
+}
+
+// ---------- newGitRefStore ----------
+func newGitRefStore(ref string, showcase bool) *gitRefStore {
+	return &gitRefStore{ref: ref, showcase: showcase}
+}
+
+func (s *gitRefStore) Name() string {
+	return fmt.Sprintf("filetree.yaml on %s", s.ref)
+}
+
+func (s *gitRefStore) Exists() bool {
+	_, err := resolveRef(s.ref)
+	return err == nil
+}
+
+func (s *gitRefStore) Load() (*FileTree, error) {
+	commit, err := resolveRef(s.ref)
+	if err != nil {
+		return nil, fmt.Errorf("no metadata found at %s, run 'gittier init' first", s.ref)
+	}
+
+	fileTree, err := readFileTree(newTreeFS(commit), "filetree.yaml")
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFileTree(fileTree); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name(), err)
+	}
+	return fileTree, nil
+}
+
+func (s *gitRefStore) Save(ft *FileTree, message string) error {
+	if s.showcase {
+		// moving the ref under a checked out branch would leave the work tree behind
+		if current, err := GetCurrentBranch(); err == nil && "refs/heads/"+current == s.ref {
+			return fmt.Errorf("cannot update %s while it is checked out", s.ref)
+		}
+	}
+
+	if err := ValidateFileTree(ft); err != nil {
+		return err
+	}
+
+	parent, _ := resolveRef(s.ref)
+	treeFS := newTreeFS(parent)
+	if err := writeFileTree(treeFS, "filetree.yaml", ft); err != nil {
+		return err
+	}
+
+	_, err := treeFS.commit(s.ref, message)
+	return err
+}
+
+func (s *gitRefStore) Remove() error {
+	if !s.Exists() {
+		return nil
+	}
+	if s.showcase {
+		return DeleteBranch(strings.TrimPrefix(s.ref, "refs/heads/"))
+	}
+	cmd := exec.Command("git", "update-ref", "-d", s.ref)
+	return cmd.Run()
+}
+
+// treeFS reads metadata files out of a commit and collects writes so they
+// can be committed on top of it without touching the work tree
+type treeFS struct {
+	base    string
+	writes  map[string][]byte
+	removed map[string]bool
+}
+
+// ---------- newTreeFS ----------
+func newTreeFS(base string) *treeFS {
+	return &treeFS{
+		base:    base,
+		writes:  make(map[string][]byte),
+		removed: make(map[string]bool),
+	}
+}
+
+func (t *treeFS) ReadFile(name string) ([]byte, error) {
+	if data, ok := t.writes[name]; ok {
+		return data, nil
+	}
+	if t.removed[name] || t.base == "" {
+		return nil, os.ErrNotExist
+	}
+
+	cmd := exec.Command("git", "cat-file", "blob", t.base+":"+name)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in %s: %w", name, t.base, os.ErrNotExist)
+	}
+	return output, nil
+}
+
+func (t *treeFS) WriteFile(name string, data []byte) error {
+	delete(t.removed, name)
+	t.writes[name] = data
+	return nil
+}
+
+func (t *treeFS) Remove(name string) error {
+	delete(t.writes, name)
+	t.removed[name] = true
+	return nil
+}
+
+// ---------- commit ----------
+// commit records the collected writes in a new commit on top of base and
+// moves ref to it, returning the new commit or base when nothing changed
+func (t *treeFS) commit(ref, message string) (string, error) {
+	if len(t.writes) == 0 && len(t.removed) == 0 {
+		return t.base, nil
+	}
+
+	// build the tree in a scratch index so the real index is never touched
+	indexFile, err := os.CreateTemp("", "gittier-index-")
+	if err != nil {
+		return "", err
+	}
+	indexFile.Close()
+	os.Remove(indexFile.Name())
+	defer os.Remove(indexFile.Name())
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+indexFile.Name())
+	run := func(stdin string, args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = env
+		if stdin != "" {
+			cmd.Stdin = strings.NewReader(stdin)
+		}
+		output, err := cmd.Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	if t.base != "" {
+		_, err = run("", "read-tree", t.base)
+	} else {
+		_, err = run("", "read-tree", "--empty")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for name := range t.writes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var indexInfo strings.Builder
+	for _, name := range names {
+		blob, err := hashObject(t.writes[name])
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&indexInfo, "100644 %s\t%s\x00", blob, filepath.ToSlash(name))
+	}
+	for name := range t.removed {
+		// mode 0 removes the path from the index
+		fmt.Fprintf(&indexInfo, "0 %s\t%s\x00", strings.Repeat("0", 40), filepath.ToSlash(name))
+	}
+	if _, err := run(indexInfo.String(), "update-index", "-z", "--index-info"); err != nil {
+		return "", err
+	}
+
+	tree, err := run("", "write-tree")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", tree, "-m", message}
+	if t.base != "" {
+		args = append(args, "-p", t.base)
+	}
+	commit, err := run("", args...)
+	if err != nil {
+		return "", err
+	}
+
+	// only move the ref if nobody else moved it since it was read
+	if _, err := run("", "update-ref", "-m", message, ref, commit, t.base); err != nil {
+		return "", err
+	}
+
+	t.base = commit
+	t.writes = make(map[string][]byte)
+	t.removed = make(map[string]bool)
+	return commit, nil
+}
+
+// ---------- resolveRef ----------
+func resolveRef(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ref %s does not exist", ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ---------- hashObject ----------
+func hashObject(data []byte) (string, error) {
+	cmd := exec.Command("git", "hash-object", "-w", "--stdin")
+	cmd.Stdin = strings.NewReader(string(data))
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to write blob: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	newTestRepo(t, map[string]string{"main.go": "package main\n"})
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() without a config: %v", err)
+	}
+	if config.Backend != "branch" {
+		t.Errorf("Backend = %q, want the default branch backend", config.Backend)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ConfigFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ConfigFile, []byte("backend: notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "add config")
+
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Backend != "notes" {
+		t.Errorf("Backend = %q, want notes from %s", config.Backend, ConfigFile)
+	}
+}
+
+func TestLoadConfigFailsWithoutMain(t *testing.T) {
+	newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	git(t, "branch", "-q", "-m", "main", "trunk")
+
+	if _, err := LoadConfig(); err == nil {
+		t.Errorf("LoadConfig() without a main branch fell back to the defaults")
+	}
+}
+
+func TestLoadConfigFailsOutsideARepository(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	_, err = LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("LoadConfig() outside a repository = %v, want git's error", err)
+	}
+}
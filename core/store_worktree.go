@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// worktreeStore keeps filetree.yaml inside a folder of the checked out
+// branch, so description changes are committed and reviewed like code
+type worktreeStore struct {
+	dir string
+}
+
+func (s *worktreeStore) Name() string {
+	return filepath.Join(s.dir, "filetree.yaml")
+}
+
+func (s *worktreeStore) Exists() bool {
+	return FileExists(s.Name())
+}
+
+func (s *worktreeStore) Load() (*FileTree, error) {
+	if !s.Exists() {
+		return nil, fmt.Errorf("%s not found, run 'gittier init' first", s.Name())
+	}
+	return ReadFileTreeFromYaml(s.Name())
+}
+
+func (s *worktreeStore) Save(ft *FileTree, message string) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	if err := WriteFileTreeToYaml(ft, s.Name()); err != nil {
+		return err
+	}
+
+	// stage but leave committing to the user so the change can go through review
+	for _, path := range MetadataPaths(s.Name()) {
+		if err := Stage(path); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+	fmt.Printf("Staged changes to %s, commit them to publish (%s)\n", s.dir, message)
+	return nil
+}
+
+func (s *worktreeStore) Remove() error {
+	if err := DeleteFile(s.Name()); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.dir, ShardDir(s.Name())))
+}
@@ -1,30 +1,200 @@
 package core
 
+import "time"
+
+// NoDescription is the placeholder given to paths nobody has described yet
+const NoDescription = "no description added"
+
+// Node kinds, following the object type and mode git records for each path
+const (
+	KindFile       = "file"
+	KindExecutable = "executable"
+	KindSymlink    = "symlink"
+	KindDir        = "dir"
+	KindSubmodule  = "submodule"
+)
+
 type FileTree struct {
-	CommitHash string               `yaml:"commit_hash"`
-	Nodes      map[string]*PathNode `yaml:"nodes"`
+	Version    int    `yaml:"version"`
+	CommitHash string `yaml:"commit_hash"`
+	ShardDepth int    `yaml:"shard_depth"`
+
+	// Tombstones keep the descriptions of deleted paths, sorted by path
+	Tombstones []*Tombstone `yaml:"tombstones"`
+	// Pending holds descriptions of paths not on main yet, sorted by path
+	Pending []*Pending `yaml:"pending"`
+	// Moves holds what gittier mv moved since the last sync, in order
+	Moves []*Move `yaml:"moves"`
+	// RulesHash identifies the config rules the descriptions were last
+	// derived with, sync applies changed rules to every path
+	RulesHash string `yaml:"rules_hash"`
+
+	// root of the path trie, one level per path segment
+	root *trieNode
+	size int
+
+	// shards not yet read from disk, nil for single file trees
+	shards *shardStore
 }
 
 type PathNode struct {
-	Path        string      `yaml:"path"`
-	Description string      `yaml:"description"`
-	IsDir       bool        `yaml:"is_dir"`
-	Children    []*PathNode `yaml:"children,omitempty"`
+	Path        string `yaml:"path"`
+	Description string `yaml:"description"`
+	IsDir       bool   `yaml:"is_dir"`
+	// Kind is one of the Kind constants
+	Kind string `yaml:"kind"`
+	// ObjectID is the blob, tree or submodule commit id on main
+	ObjectID string `yaml:"object"`
+	// Mode is the git file mode, e.g. 100644 or 160000
+	Mode string `yaml:"mode"`
+	// CopiedFrom is the path a copy inherited its description from, cleared
+	// once the copy gets a description of its own
+	CopiedFrom string `yaml:"copied_from"`
+	// DescribedObject is the object id the description was written or last
+	// confirmed against
+	DescribedObject string `yaml:"described_object"`
+	// Stale is set by sync once the content moved on too far from DescribedObject
+	Stale bool `yaml:"stale"`
+	// SourceManaged is set when the description comes from an annotation on
+	// main, a gittier: comment in the file or a .gittier file in the folder
+	SourceManaged bool `yaml:"source_managed"`
+	// Rule is the pattern of the config rule that produced the description,
+	// empty for descriptions someone wrote
+	Rule string `yaml:"rule"`
+	// Pack is the convention pack and pattern that pre-filled the
+	// description, e.g. "go cmd/*/", until someone describes the path
+	Pack string `yaml:"pack"`
+}
+
+// Tombstone is the description of a path that was deleted on main, kept so
+// it can come back when the path or its content reappears
+type Tombstone struct {
+	Path        string `yaml:"path"`
+	Description string `yaml:"description"`
+	IsDir       bool   `yaml:"is_dir,omitempty"`
+	// ObjectID is the last object id the path had on main
+	ObjectID        string `yaml:"object,omitempty"`
+	DescribedObject string `yaml:"described_object,omitempty"`
+	// DeletedIn is the commit on main that deleted the path, or the commit
+	// it was found missing at when the history since the last sync is gone
+	DeletedIn string `yaml:"deleted_in"`
+}
+
+// Pending is a description written ahead of its path, attached by sync once
+// the path shows up on main
+type Pending struct {
+	Path        string `yaml:"path"`
+	Description string `yaml:"description"`
+	// Added is when the description was written
+	Added time.Time `yaml:"added"`
+}
+
+// Move is a path moved with gittier mv, already applied to the tree and
+// replayed by sync instead of relying on rename detection
+type Move struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
 }
 
 // ---------- NewFileTree ----------
 func NewFileTree(commitHash string) *FileTree {
 	return &FileTree{
+		Version:    SchemaVersion,
 		CommitHash: commitHash,
-		Nodes:      make(map[string]*PathNode),
+		root:       newTrieNode(nil),
 	}
 }
 
 // ---------- NewPathNode ----------
 func NewPathNode(lsTreeItem string, isDir bool) *PathNode {
+	kind := KindFile
+	if isDir {
+		kind = KindDir
+	}
+
 	return &PathNode{
 		Path:        lsTreeItem,
-		Description: "no description added",
+		Description: NoDescription,
 		IsDir:       isDir,
+		Kind:        kind,
+		Mode:        modeForKind(kind),
+	}
+}
+
+// ---------- NewPathNodeFromEntry ----------
+func NewPathNodeFromEntry(entry LsTreeEntry) *PathNode {
+	kind := kindForMode(entry.Mode)
+	return &PathNode{
+		Path:        entry.Path,
+		Description: NoDescription,
+		IsDir:       kind == KindDir,
+		Kind:        kind,
+		ObjectID:    entry.ObjectID,
+		Mode:        entry.Mode,
+	}
+}
+
+// ---------- SetObject ----------
+// SetObject updates what git knows about the node, leaving its description alone
+func (node *PathNode) SetObject(other *PathNode) {
+	// descriptions from before object ids were tracked count from the last known one
+	if node.DescribedObject == "" && IsDescribed(node) && node.Rule == "" {
+		node.DescribedObject = node.ObjectID
+	}
+	node.IsDir = other.IsDir
+	node.Kind = other.Kind
+	node.ObjectID = other.ObjectID
+	node.Mode = other.Mode
+}
+
+// ---------- SetDescription ----------
+func (node *PathNode) SetDescription(description string) {
+	node.Description = description
+	node.CopiedFrom = ""
+	node.Rule = ""
+	node.Pack = ""
+	node.DescribedObject = ""
+	if hasDescription(description) {
+		node.DescribedObject = node.ObjectID
+	}
+	node.Stale = false
+}
+
+// ---------- ConfirmDescription ----------
+// ConfirmDescription keeps the description as it is for the current content
+func (node *PathNode) ConfirmDescription() {
+	node.DescribedObject = node.ObjectID
+	node.Stale = false
+}
+
+// ---------- kindForMode ----------
+func kindForMode(mode string) string {
+	switch mode {
+	case "040000":
+		return KindDir
+	case "100755":
+		return KindExecutable
+	case "120000":
+		return KindSymlink
+	case "160000":
+		return KindSubmodule
+	default:
+		return KindFile
+	}
+}
+
+// ---------- modeForKind ----------
+func modeForKind(kind string) string {
+	switch kind {
+	case KindDir:
+		return "040000"
+	case KindExecutable:
+		return "100755"
+	case KindSymlink:
+		return "120000"
+	case KindSubmodule:
+		return "160000"
+	default:
+		return "100644"
 	}
 }
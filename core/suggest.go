@@ -0,0 +1,348 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+)
+
+// Suggestion is a description proposed for an undescribed path, or for one
+// a convention pack filled in
+type Suggestion struct {
+	Path        string
+	Description string
+	// Source says what the description was taken from
+	Source string
+}
+
+// wellKnownFiles describes files whose name says what they are for
+var wellKnownFiles = map[string]string{
+	".gitignore":         "Paths git leaves untracked",
+	".gitattributes":     "Git attributes such as line endings and diff drivers",
+	".gitmodules":        "Submodules used by the repository",
+	".editorconfig":      "Editor settings shared across the project",
+	".dockerignore":      "Paths left out of the Docker build context",
+	".gittier":           "Description of this folder for gittier",
+	"go.sum":             "Checksums of the Go module dependencies",
+	"go.work":            "Go workspace definition",
+	"go.work.sum":        "Checksums of the Go workspace dependencies",
+	"package.json":       "Node.js package manifest and scripts",
+	"package-lock.json":  "Pinned versions of the npm dependencies",
+	"yarn.lock":          "Pinned versions of the Yarn dependencies",
+	"Cargo.toml":         "Rust crate manifest",
+	"Cargo.lock":         "Pinned versions of the Rust dependencies",
+	"requirements.txt":   "Python dependencies",
+	"pyproject.toml":     "Python project configuration",
+	"Gemfile":            "Ruby dependencies",
+	"Gemfile.lock":       "Pinned versions of the Ruby dependencies",
+	"Makefile":           "Build and development tasks",
+	"Dockerfile":         "Container image build",
+	"docker-compose.yml": "Local services for development",
+	"CHANGELOG.md":       "Notable changes in each release",
+	"CONTRIBUTING.md":    "How to contribute to the project",
+	"CODE_OF_CONDUCT.md": "Community code of conduct",
+	"SECURITY.md":        "How to report security issues",
+	"CODEOWNERS":         "Owners who review changes to each path",
+	".github":            "GitHub workflows and repository settings",
+	"workflows":          "GitHub Actions workflows",
+}
+
+// licenseFiles are described by the first line of the license text
+var licenseFiles = map[string]bool{
+	"LICENSE": true, "LICENSE.md": true, "LICENSE.txt": true, "COPYING": true, "UNLICENSE": true,
+}
+
+// readmeFiles are read, in this order, to describe their folder
+var readmeFiles = []string{"README.md", "README", "README.txt", "README.rst", "readme.md"}
+
+// ---------- Suggest ----------
+// Suggest proposes descriptions for the paths at and below root that are
+// undescribed or only have a convention pack's text, from what main already
+// says about them: well-known file names, go.mod, Go package comments, folder
+// READMEs and file header comments, in that order of preference. Nothing is
+// changed in the tree
+func Suggest(ft *FileTree, root string) ([]Suggestion, error) {
+	nodes := ft.getSubtree(root)
+
+	// blobs worth reading: go.mod, licenses, and for undescribed folders
+	// their READMEs and Go files, for undescribed files their headers
+	var blobs []string
+	for _, node := range nodes {
+		if node.ObjectID == "" || (node.Kind != KindFile && node.Kind != KindExecutable) {
+			continue
+		}
+		name := path.Base(node.Path)
+		if !node.IsDir && openForSuggestion(node) && (name == "go.mod" || licenseFiles[name] || syntaxesFor(name) != nil) {
+			blobs = append(blobs, node.ObjectID)
+			continue
+		}
+		if parent := ft.GetNode(parentOf(node.Path)); parent != nil && openForSuggestion(parent) &&
+			(isReadme(name) || path.Ext(name) == ".go") {
+			blobs = append(blobs, node.ObjectID)
+		}
+	}
+
+	contents := map[string][]byte{}
+	if len(blobs) > 0 {
+		var err error
+		if contents, err = readBlobs(blobs, blobHead); err != nil {
+			return nil, err
+		}
+	}
+
+	var suggestions []Suggestion
+	for _, node := range nodes {
+		if !openForSuggestion(node) {
+			continue
+		}
+
+		var description, source string
+		if node.IsDir {
+			description, source = suggestForDir(ft, node, contents)
+		} else {
+			description, source = suggestForFile(node, contents[node.ObjectID])
+		}
+		if description != "" && description != node.Description {
+			suggestions = append(suggestions, Suggestion{Path: node.Path, Description: description, Source: source})
+		}
+	}
+	return suggestions, nil
+}
+
+// ---------- openForSuggestion ----------
+// openForSuggestion reports whether node may get a suggestion: it has no
+// description, or only the generic one a convention pack filled in
+func openForSuggestion(node *PathNode) bool {
+	return !IsDescribed(node) || node.Pack != ""
+}
+
+// ---------- suggestForFile ----------
+func suggestForFile(node *PathNode, content []byte) (string, string) {
+	name := path.Base(node.Path)
+
+	if name == "go.mod" {
+		if module := goModule(content); module != "" {
+			return "Go module " + module, "go.mod"
+		}
+	}
+	if licenseFiles[name] {
+		if title := firstLine(content); title != "" && len(title) <= 60 {
+			return "License terms (" + title + ")", "license text"
+		}
+		return "License terms of the project", "file name"
+	}
+	if description, ok := wellKnownFiles[name]; ok {
+		return description, "file name"
+	}
+
+	syntaxes := syntaxesFor(name)
+	if syntaxes == nil {
+		return "", ""
+	}
+	// the package comment describes the folder rather than the file
+	if path.Ext(name) == ".go" && goPackageDoc(content) != "" {
+		if name == "doc.go" {
+			return "Package documentation", "file name"
+		}
+		return "", ""
+	}
+	if header := headerComment(content, syntaxes); header != "" {
+		return header, "header comment"
+	}
+	return "", ""
+}
+
+// ---------- suggestForDir ----------
+func suggestForDir(ft *FileTree, node *PathNode, contents map[string][]byte) (string, string) {
+	if description, ok := wellKnownFiles[path.Base(node.Path)]; ok {
+		return description, "folder name"
+	}
+
+	children := ft.GetChildNodes(node.Path)
+
+	// doc.go holds the package comment by convention, any other file may too
+	for _, preferDoc := range []bool{true, false} {
+		for _, child := range children {
+			name := path.Base(child.Path)
+			if child.IsDir || path.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") || (name == "doc.go") != preferDoc {
+				continue
+			}
+			if doc := goPackageDoc(contents[child.ObjectID]); doc != "" {
+				return doc, "package comment in " + name
+			}
+		}
+	}
+
+	for _, readme := range readmeFiles {
+		for _, child := range children {
+			if child.IsDir || path.Base(child.Path) != readme {
+				continue
+			}
+			if summary := readmeSummary(contents[child.ObjectID], path.Base(node.Path)); summary != "" {
+				return summary, readme
+			}
+		}
+	}
+	return "", ""
+}
+
+// ---------- isReadme ----------
+func isReadme(name string) bool {
+	for _, readme := range readmeFiles {
+		if name == readme {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------- goModule ----------
+func goModule(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
+
+// ---------- goPackageDoc ----------
+// goPackageDoc returns the first sentence of the "// Package x ..." or
+// "// Command x ..." comment right above the package clause
+func goPackageDoc(content []byte) string {
+	var comment []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "//go:") || strings.HasPrefix(line, "// +build"):
+			comment = nil
+		case strings.HasPrefix(line, "//"):
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "//")))
+		case strings.HasPrefix(line, "package "):
+			text := strings.Join(comment, " ")
+			if strings.HasPrefix(text, "Package ") || strings.HasPrefix(text, "Command ") {
+				return firstSentence(text)
+			}
+			return ""
+		default:
+			// a blank line or anything else detaches the comment from the clause
+			comment = nil
+		}
+	}
+	return ""
+}
+
+// ---------- headerComment ----------
+// headerComment returns the first sentence of the comment a file opens with,
+// skipping shebangs, build constraints, generated-code markers and licenses
+func headerComment(content []byte, syntaxes []commentSyntax) string {
+	var comment []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for i := 0; i < annotationLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			continue
+		}
+
+		text, ok := commentText(line, syntaxes)
+		if !ok {
+			if len(comment) > 0 {
+				break
+			}
+			if line == "" {
+				continue
+			}
+			// code before any comment, the file has no header
+			return ""
+		}
+
+		if text == "" {
+			if len(comment) > 0 {
+				break
+			}
+			continue
+		}
+		if ignoredHeader(text) {
+			comment = nil
+			continue
+		}
+		comment = append(comment, text)
+	}
+	return firstSentence(strings.Join(comment, " "))
+}
+
+// ---------- commentText ----------
+func commentText(line string, syntaxes []commentSyntax) (string, bool) {
+	for _, syntax := range syntaxes {
+		rest, ok := strings.CutPrefix(line, syntax.open)
+		if !ok {
+			continue
+		}
+		if syntax.close != "" {
+			rest = strings.TrimSuffix(strings.TrimSpace(rest), syntax.close)
+		}
+		return strings.TrimSpace(rest), true
+	}
+	return "", false
+}
+
+// ---------- ignoredHeader ----------
+func ignoredHeader(text string) bool {
+	lower := strings.ToLower(text)
+	for _, prefix := range []string{"copyright", "spdx-license-identifier", "licensed under", "code generated", "go:build", "+build", "-*-", "eslint-", "prettier-", "nolint", AnnotationMarker} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------- readmeSummary ----------
+// readmeSummary is the first heading of a README, or its first line of text
+// when the heading only repeats the folder name
+func readmeSummary(content []byte, dirName string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "<!--") || strings.HasPrefix(line, "![") || strings.HasPrefix(line, "[![") {
+			continue
+		}
+		// setext underlines belong to the heading before them
+		if strings.Trim(line, "=-") == "" {
+			continue
+		}
+
+		text := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if text == "" || strings.EqualFold(text, dirName) {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			return text
+		}
+		return firstSentence(text)
+	}
+	return ""
+}
+
+// ---------- firstLine ----------
+func firstLine(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// ---------- firstSentence ----------
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSuffix(text, ".")
+}
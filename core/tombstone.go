@@ -0,0 +1,174 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ---------- bury ----------
+// bury deletes p and everything below it, leaving a tombstone for every
+// described node so its description can come back with the path
+func (ft *FileTree) bury(p, commit string) error {
+	for _, node := range ft.getSubtree(p) {
+		// rule descriptions come back on their own
+		if !IsDescribed(node) || node.Rule != "" {
+			continue
+		}
+		ft.addTombstone(&Tombstone{
+			Path:            node.Path,
+			Description:     node.Description,
+			IsDir:           node.IsDir,
+			ObjectID:        node.ObjectID,
+			DescribedObject: node.DescribedObject,
+			DeletedIn:       commit,
+		})
+	}
+	return ft.DeleteNode(p)
+}
+
+// ---------- DateTombstones ----------
+// DateTombstones points the tombstones left by a sync from oldCommit at the
+// commit that deleted their path. bury records the commit synced to, which
+// only deleted the path when it is the one commit since oldCommit
+func (ft *FileTree) DateTombstones(oldCommit string) error {
+	var buried []*Tombstone
+	for _, tombstone := range ft.Tombstones {
+		if tombstone.DeletedIn == ft.CommitHash {
+			buried = append(buried, tombstone)
+		}
+	}
+	if len(buried) == 0 || oldCommit == ft.CommitHash {
+		return nil
+	}
+
+	// newest first, every commit as "\x01<hash>" followed by the paths it
+	// deleted; renames count as deletes, sync may not have followed them
+	cmd := exec.Command("git", "log", "-z", "--no-renames", "--diff-filter=D", "--name-only", "--format=%x01%H", oldCommit+".."+ft.CommitHash)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to find the commits that deleted paths: %w", err)
+	}
+
+	// commits newest first, and for every deleted path its newest deletion
+	var commits []string
+	deletedIn := make(map[string]int)
+	for _, field := range bytes.Split(output, []byte{0}) {
+		name := strings.TrimPrefix(string(field), "\n")
+		switch {
+		case strings.HasPrefix(name, "\x01"):
+			commits = append(commits, name[1:])
+		case name != "" && len(commits) > 0:
+			if _, seen := deletedIn[name]; !seen {
+				deletedIn[name] = len(commits) - 1
+			}
+		}
+	}
+
+	for _, tombstone := range buried {
+		newest := -1
+		for name, i := range deletedIn {
+			// git only deletes files, a folder goes with the last of them
+			below := tombstone.IsDir && strings.HasPrefix(name, tombstone.Path+"/")
+			if (name == tombstone.Path || below) && (newest < 0 || i < newest) {
+				newest = i
+			}
+		}
+		if newest >= 0 {
+			tombstone.DeletedIn = commits[newest]
+		}
+	}
+	return nil
+}
+
+// ---------- addTombstone ----------
+// addTombstone replaces any older tombstone for the same path
+func (ft *FileTree) addTombstone(tombstone *Tombstone) {
+	for i, existing := range ft.Tombstones {
+		if existing.Path == tombstone.Path {
+			ft.Tombstones[i] = tombstone
+			return
+		}
+	}
+
+	ft.Tombstones = append(ft.Tombstones, tombstone)
+	sort.Slice(ft.Tombstones, func(i, j int) bool {
+		return ft.Tombstones[i].Path < ft.Tombstones[j].Path
+	})
+}
+
+// ---------- restore ----------
+// restore gives a node that just appeared the description of the tombstone
+// at its path or, for files, of the only tombstone with the same blob id.
+// The tombstone is removed and returned, nil when nothing matched
+func (ft *FileTree) restore(node *PathNode, objectID string) *Tombstone {
+	if IsDescribed(node) {
+		return nil
+	}
+
+	match := -1
+	for i, tombstone := range ft.Tombstones {
+		if tombstone.Path == node.Path && tombstone.IsDir == node.IsDir {
+			match = i
+			break
+		}
+	}
+	if match < 0 && !node.IsDir && objectID != "" {
+		for i, tombstone := range ft.Tombstones {
+			if tombstone.IsDir || tombstone.ObjectID != objectID {
+				continue
+			}
+			if match >= 0 {
+				// several deleted files had this content, none of them is the obvious one
+				return nil
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil
+	}
+
+	tombstone := ft.Tombstones[match]
+	node.Description = tombstone.Description
+	node.DescribedObject = tombstone.DescribedObject
+	ft.Tombstones = append(ft.Tombstones[:match], ft.Tombstones[match+1:]...)
+	return tombstone
+}
+
+// ---------- PurgeTombstones ----------
+// PurgeTombstones forgets the tombstones of the given paths, or all of them
+// when no path is given, and returns how many were removed
+func (ft *FileTree) PurgeTombstones(paths []string) (int, error) {
+	if len(paths) == 0 {
+		count := len(ft.Tombstones)
+		ft.Tombstones = nil
+		return count, nil
+	}
+
+	purge := make(map[string]bool)
+	for _, p := range paths {
+		purge[p] = true
+	}
+
+	var kept []*Tombstone
+	for _, tombstone := range ft.Tombstones {
+		if purge[tombstone.Path] {
+			delete(purge, tombstone.Path)
+			continue
+		}
+		kept = append(kept, tombstone)
+	}
+
+	for _, p := range paths {
+		if purge[p] {
+			return 0, fmt.Errorf("no tombstone for %s", p)
+		}
+	}
+
+	count := len(ft.Tombstones) - len(kept)
+	ft.Tombstones = kept
+	return count, nil
+}
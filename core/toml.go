@@ -0,0 +1,42 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ---------- writeToml ----------
+func writeToml(w io.Writer, doc *exportDocument) error {
+	// TOML strings are UTF-8 only, anything else would be silently replaced
+	for _, record := range doc.Nodes {
+		if !utf8.ValidString(record.Path) || !utf8.ValidString(record.Description) {
+			return fmt.Errorf("%q is not valid UTF-8, which TOML cannot hold, export it as csv instead", record.Path)
+		}
+	}
+
+	encoder := toml.NewEncoder(w)
+	encoder.Indent = ""
+	return encoder.Encode(doc)
+}
+
+// ---------- readToml ----------
+func readToml(r io.Reader) (*exportDocument, error) {
+	doc := &exportDocument{}
+	metadata, err := toml.NewDecoder(r).Decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("unknown keys %s", strings.Join(keys, ", "))
+	}
+	return doc, nil
+}
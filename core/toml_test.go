@@ -0,0 +1,81 @@
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTomlRoundTrip(t *testing.T) {
+	doc := &exportDocument{
+		Version:    SchemaVersion,
+		CommitHash: "0123456789abcdef0123456789abcdef01234567",
+		Nodes: []Record{
+			{Path: "cmd", Description: "Commands", IsDir: true},
+			{Path: "cmd/a b.go", Description: `Quotes " and \ backslashes`},
+			{Path: "docs/naïve.md", Description: "Line\nbreaks\tand tabs, ünïcödé, emoji 🌳"},
+			{Path: "main.go", Description: ""},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeToml(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readToml(&buf)
+	if err != nil {
+		t.Fatalf("reading back %q: %v", buf.String(), err)
+	}
+	if !reflect.DeepEqual(got, doc) {
+		t.Errorf("round trip = %+v, want %+v", got, doc)
+	}
+}
+
+func TestReadTomlAcceptsValidToml(t *testing.T) {
+	input := `# exported descriptions
+version = 2 # schema
+commit_hash = 'abc'
+
+[[nodes]] # the first node
+"path" = "cmd"
+description = """
+Commands"""
+is_dir = true
+
+[[ nodes ]]
+path = 'main.go'
+`
+	records, err := ParseRecords("toml", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Record{
+		{Path: "cmd", Description: "Commands", IsDir: true},
+		{Path: "main.go"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ParseRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestReadTomlRejectsUnknownKeys(t *testing.T) {
+	input := "version = 2\n\n[[nodes]]\npath = \"cmd\"\nowner = \"me\"\n"
+	if _, err := ParseRecords("toml", strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), "nodes.owner") {
+		t.Errorf("ParseRecords error = %v, want one naming nodes.owner", err)
+	}
+}
+
+func TestWriteTomlRejectsInvalidUtf8(t *testing.T) {
+	doc := &exportDocument{
+		Version: SchemaVersion,
+		Nodes:   []Record{{Path: "latin1-\xe9.txt", Description: "Not UTF-8"}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeToml(&buf, doc); err == nil {
+		t.Errorf("writeToml wrote %q, want an error", buf.String())
+	}
+}
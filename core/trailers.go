@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// DescribeTrailerKey is the commit message trailer sync reads descriptions from
+const DescribeTrailerKey = "Gittier-Describe"
+
+// DescribeTrailer is one "Gittier-Describe: <path> = <description>" trailer
+type DescribeTrailer struct {
+	// Commit is the commit on main whose message held the trailer
+	Commit      string
+	Path        string
+	Description string
+}
+
+// ---------- ReadDescribeTrailers ----------
+// ReadDescribeTrailers collects the describe trailers of every commit after
+// oldCommit up to branch, oldest commit first. Trailers that do not have the
+// form "<path> = <description>" are returned as problems
+func ReadDescribeTrailers(oldCommit, branch string) ([]DescribeTrailer, []string, error) {
+	cmd := exec.Command("git", "log", "--topo-order", "--reverse",
+		"--format=%x1e%H%n%(trailers:key="+DescribeTrailerKey+",valueonly,unfold)",
+		oldCommit+".."+branch)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read commit messages: %w", err)
+	}
+
+	var trailers []DescribeTrailer
+	var problems []string
+	for _, record := range strings.Split(string(output), "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if len(lines) < 2 {
+			continue
+		}
+
+		commit := lines[0]
+		for _, value := range lines[1:] {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			trailer, err := parseDescribeTrailer(commit, value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", commit[:7], err))
+				continue
+			}
+			trailers = append(trailers, trailer)
+		}
+	}
+	return trailers, problems, nil
+}
+
+// ---------- parseDescribeTrailer ----------
+func parseDescribeTrailer(commit, value string) (DescribeTrailer, error) {
+	p, description, ok := strings.Cut(value, "=")
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	description = strings.TrimSpace(description)
+	if !ok || p == "" || description == "" {
+		return DescribeTrailer{}, fmt.Errorf("expected '%s: <path> = <description>', got '%s'", DescribeTrailerKey, value)
+	}
+	if p = path.Clean(p); p == "." || strings.HasPrefix(p, "../") {
+		return DescribeTrailer{}, fmt.Errorf("'%s' is not a path inside the repository", p)
+	}
+
+	return DescribeTrailer{Commit: commit, Path: p, Description: description}, nil
+}
+
+// ---------- ApplyDescribeTrailers ----------
+// ApplyDescribeTrailers describes the paths named by trailers, a later
+// trailer for the same path winning over an earlier one. It returns the
+// trailers that took effect and those naming a path that is not in the tree
+func (ft *FileTree) ApplyDescribeTrailers(trailers []DescribeTrailer) ([]DescribeTrailer, []DescribeTrailer) {
+	latest := make(map[string]int)
+	for i, trailer := range trailers {
+		latest[trailer.Path] = i
+	}
+
+	var applied, missing []DescribeTrailer
+	for i, trailer := range trailers {
+		if latest[trailer.Path] != i {
+			continue
+		}
+		node := ft.GetNode(trailer.Path)
+		if node == nil {
+			missing = append(missing, trailer)
+			continue
+		}
+		node.SetDescription(trailer.Description)
+		applied = append(applied, trailer)
+	}
+	return applied, missing
+}
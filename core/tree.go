@@ -0,0 +1,87 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorDim    = "\033[2m"
+	colorBlue   = "\033[34m"
+	colorGreen  = "\033[32m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+)
+
+// ---------- RenderTerminalTree ----------
+// RenderTerminalTree draws the tree for a terminal. history, when not nil,
+// adds the last commit on main touching each path after its description
+func RenderTerminalTree(ft *FileTree, opts RenderOptions, color bool, history map[string]CommitInfo) (string, error) {
+	nodes, err := selectRenderRoot(ft, opts)
+	if err != nil {
+		return "", err
+	}
+
+	paint := func(code, s string) string {
+		if !color || s == "" {
+			return s
+		}
+		return code + s + colorReset
+	}
+
+	var b strings.Builder
+	b.WriteString(paint(colorBold+colorBlue, displayPath(opts.Root)) + "\n")
+
+	var walk func(nodes []*renderNode, prefix string)
+	walk = func(nodes []*renderNode, prefix string) {
+		for i, rn := range nodes {
+			branch, indent := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			name := displayName(rn.node)
+			if rn.node.IsDir {
+				name = paint(colorBold+colorBlue, name)
+			}
+
+			description := paint(colorRed, NoDescription)
+			if IsDescribed(rn.node) {
+				description = paint(colorGreen, strings.Join(strings.Fields(rn.node.Description), " "))
+			}
+			if rn.node.Stale {
+				description += "  " + paint(colorYellow, "(possibly stale)")
+			}
+
+			b.WriteString(paint(colorDim, prefix+branch) + name + "  " + description)
+			if history != nil {
+				if commit, ok := history[rn.node.Path]; ok {
+					b.WriteString("  " + paint(colorDim, fmt.Sprintf("[%s %s %s: %s]", commit.ShortHash, commit.Date, commit.Author, commit.Subject)))
+				}
+			}
+			b.WriteString("\n")
+
+			walk(rn.children, prefix+indent)
+		}
+	}
+	walk(nodes, "")
+
+	return b.String(), nil
+}
+
+// ---------- CollectPaths ----------
+// CollectPaths lists every path RenderTerminalTree would show for opts
+func CollectPaths(ft *FileTree, opts RenderOptions) ([]string, error) {
+	nodes, err := selectRenderRoot(ft, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, dn := range diagramOrder(nodes, opts.Root) {
+		paths = append(paths, dn.node.Path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// trieNode is one path segment of a FileTree. node is nil for the root and
+// for folders whose children were added before the folder itself
+type trieNode struct {
+	node     *PathNode
+	children map[string]*trieNode
+	// names caches the sorted keys of children, nil after a change
+	names []string
+}
+
+// ---------- newTrieNode ----------
+func newTrieNode(node *PathNode) *trieNode {
+	return &trieNode{node: node}
+}
+
+// ---------- splitPath ----------
+func splitPath(p string) []string {
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// ---------- sortedNames ----------
+func (t *trieNode) sortedNames() []string {
+	if t.names != nil || len(t.children) == 0 {
+		return t.names
+	}
+
+	names := make([]string, 0, len(t.children))
+	for name := range t.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	t.names = names
+	return names
+}
+
+// ---------- setChild ----------
+func (t *trieNode) setChild(name string, child *trieNode) {
+	if t.children == nil {
+		t.children = make(map[string]*trieNode)
+	}
+	if _, exists := t.children[name]; !exists {
+		t.names = nil
+	}
+	t.children[name] = child
+}
+
+// ---------- removeChild ----------
+func (t *trieNode) removeChild(name string) {
+	delete(t.children, name)
+	t.names = nil
+}
+
+// ---------- count ----------
+// count returns how many nodes t and its descendants hold
+func (t *trieNode) count() int {
+	n := 0
+	if t.node != nil {
+		n = 1
+	}
+	for _, child := range t.children {
+		n += child.count()
+	}
+	return n
+}
+
+// ---------- clone ----------
+func (t *trieNode) clone() *trieNode {
+	copied := newTrieNode(nil)
+	if t.node != nil {
+		node := *t.node
+		copied.node = &node
+	}
+	if len(t.children) > 0 {
+		copied.children = make(map[string]*trieNode, len(t.children))
+		for name, child := range t.children {
+			copied.children[name] = child.clone()
+		}
+	}
+	return copied
+}
+
+// ---------- rebase ----------
+// rebase rewrites the paths of every node below t after t moved to p
+func (t *trieNode) rebase(p string) {
+	if t.node != nil {
+		t.node.Path = p
+	}
+	for name, child := range t.children {
+		child.rebase(joinPath(p, name))
+	}
+}
+
+// ---------- walk ----------
+// walk visits t and everything below it, children in name order. pre runs
+// before a node's children and post after them, either may be nil
+func (t *trieNode) walk(p string, pre, post func(p string, t *trieNode)) {
+	if pre != nil {
+		pre(p, t)
+	}
+	for _, name := range t.sortedNames() {
+		t.children[name].walk(joinPath(p, name), pre, post)
+	}
+	if post != nil {
+		post(p, t)
+	}
+}
+
+// ---------- find ----------
+// find returns the trie node at p, or nil, without loading any shards
+func (ft *FileTree) find(p string) *trieNode {
+	t := ft.root
+	for _, name := range splitPath(p) {
+		t = t.children[name]
+		if t == nil {
+			return nil
+		}
+	}
+	return t
+}
+
+// ---------- ensure ----------
+// ensure returns the trie node at p, creating empty segments along the way
+func (ft *FileTree) ensure(p string) *trieNode {
+	t := ft.root
+	for _, name := range splitPath(p) {
+		child := t.children[name]
+		if child == nil {
+			child = newTrieNode(nil)
+			t.setChild(name, child)
+		}
+		t = child
+	}
+	return t
+}
+
+// ---------- insert ----------
+// insert places node at its path, keeping whatever is already below it
+func (ft *FileTree) insert(node *PathNode) {
+	t := ft.ensure(node.Path)
+	if t.node == nil {
+		ft.size++
+	}
+	t.node = node
+}
+
+// ---------- detach ----------
+// detach cuts the subtree at p out of the trie and returns it
+func (ft *FileTree) detach(p string) *trieNode {
+	if p == "" {
+		return nil
+	}
+
+	parent := ft.find(parentOf(p))
+	if parent == nil {
+		return nil
+	}
+	name := path.Base(p)
+	t := parent.children[name]
+	if t == nil {
+		return nil
+	}
+
+	parent.removeChild(name)
+	ft.size -= t.count()
+	return t
+}
+
+// ---------- attach ----------
+// attach places a subtree returned by detach at p, replacing whatever was there
+func (ft *FileTree) attach(p string, t *trieNode) {
+	ft.detach(p)
+
+	parent := ft.ensure(parentOf(p))
+	parent.setChild(path.Base(p), t)
+	t.rebase(p)
+	ft.size += t.count()
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// benchmark tree shape: benchDirs top-level folders with benchSubdirs
+// folders each, holding benchFiles files, a little over 100k nodes in total
+const (
+	benchDirs    = 100
+	benchSubdirs = 10
+	benchFiles   = 100
+)
+
+// ---------- buildBenchTree ----------
+func buildBenchTree() *FileTree {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	for d := 0; d < benchDirs; d++ {
+		dir := fmt.Sprintf("dir%03d", d)
+		fileTree.AddNode(NewPathNode(dir, true))
+		for s := 0; s < benchSubdirs; s++ {
+			subdir := fmt.Sprintf("%s/sub%02d", dir, s)
+			fileTree.AddNode(NewPathNode(subdir, true))
+			for f := 0; f < benchFiles; f++ {
+				fileTree.AddNode(NewPathNode(fmt.Sprintf("%s/file%03d.go", subdir, f), false))
+			}
+		}
+	}
+	return fileTree
+}
+
+// ---------- buildPrefixTree ----------
+// buildPrefixTree has cmd next to cmdx, whose paths start with the same letters
+func buildPrefixTree() *FileTree {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	for _, p := range []string{"cmd", "cmd/a.go", "cmd/sub", "cmd/sub/b.go", "cmdx", "cmdx/c.go", "cmdx/sub", "cmdx/sub/d.go", "main.go"} {
+		fileTree.AddNode(NewPathNode(p, p == "cmd" || p == "cmdx" || p == "cmd/sub" || p == "cmdx/sub"))
+	}
+	return fileTree
+}
+
+// ---------- paths ----------
+func paths(nodes []*PathNode) []string {
+	var result []string
+	for _, node := range nodes {
+		result = append(result, node.Path)
+	}
+	return result
+}
+
+func TestGetChildNodesIgnoresPrefixSiblings(t *testing.T) {
+	fileTree := buildPrefixTree()
+
+	got := paths(fileTree.GetChildNodes("cmd"))
+	want := []string{"cmd/a.go", "cmd/sub"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetChildNodes(cmd) = %v, want %v", got, want)
+	}
+
+	got = paths(fileTree.GetChildNodes(""))
+	want = []string{"cmd", "cmdx", "main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetChildNodes(\"\") = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateNodePathIgnoresPrefixSiblings(t *testing.T) {
+	fileTree := buildPrefixTree()
+
+	if err := fileTree.UpdateNodePath("cmd", "tools"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"tools", "tools/a.go", "tools/sub/b.go", "cmdx", "cmdx/c.go", "cmdx/sub/d.go"} {
+		if node := fileTree.GetNode(p); node == nil || node.Path != p {
+			t.Errorf("GetNode(%s) = %v after moving cmd", p, node)
+		}
+	}
+	for _, p := range []string{"cmd", "cmd/a.go", "toolsx", "toolsx/c.go"} {
+		if fileTree.HasNode(p) {
+			t.Errorf("%s still exists after moving cmd", p)
+		}
+	}
+}
+
+func TestUpdateNodePathRewritesOnlyTheMovedSegment(t *testing.T) {
+	fileTree := NewFileTree("0000000000000000000000000000000000000000")
+	for _, p := range []string{"a", "a/a", "a/a/a.go"} {
+		fileTree.AddNode(NewPathNode(p, p != "a/a/a.go"))
+	}
+
+	if err := fileTree.UpdateNodePath("a/a", "a/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := paths(GetDfsOrder(fileTree))
+	want := []string{"a/b/a.go", "a/b", "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths after moving a/a = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteNodeIgnoresPrefixSiblings(t *testing.T) {
+	fileTree := buildPrefixTree()
+
+	if err := fileTree.DeleteNode("cmd"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"cmd", "cmd/a.go", "cmd/sub/b.go"} {
+		if fileTree.HasNode(p) {
+			t.Errorf("%s still exists after deleting cmd", p)
+		}
+	}
+	for _, p := range []string{"cmdx", "cmdx/c.go", "cmdx/sub", "cmdx/sub/d.go", "main.go"} {
+		if !fileTree.HasNode(p) {
+			t.Errorf("%s was removed with cmd", p)
+		}
+	}
+	if fileTree.Len() != 5 {
+		t.Errorf("Len() = %d after deleting cmd, want 5", fileTree.Len())
+	}
+}
+
+func TestParentOf(t *testing.T) {
+	tests := map[string]string{
+		"main.go":      "",
+		"cmd":          "",
+		"cmd/a.go":     "cmd",
+		"cmd/sub/b.go": "cmd/sub",
+		"":             "",
+	}
+	for p, want := range tests {
+		if got := parentOf(p); got != want {
+			t.Errorf("parentOf(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		buildBenchTree()
+	}
+}
+
+func BenchmarkGetNode(b *testing.B) {
+	fileTree := buildBenchTree()
+	deepPath := fmt.Sprintf("dir%03d/sub%02d/file%03d.go", benchDirs/2, benchSubdirs/2, benchFiles/2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fileTree.GetNode(deepPath)
+	}
+}
+
+func BenchmarkGetChildNodes(b *testing.B) {
+	fileTree := buildBenchTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fileTree.GetChildNodes("dir050/sub05")
+	}
+}
+
+func BenchmarkGetDfsOrder(b *testing.B) {
+	fileTree := buildBenchTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GetDfsOrder(fileTree)
+	}
+}
+
+func BenchmarkUpdateNodePath(b *testing.B) {
+	fileTree := buildBenchTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		from, to := "dir050/sub05", "dir050/moved"
+		if !fileTree.HasNode(from) {
+			from, to = to, from
+		}
+		if err := fileTree.UpdateNodePath(from, to); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeleteNode(b *testing.B) {
+	fileTree := buildBenchTree()
+	deepPath := fmt.Sprintf("dir%03d/sub%02d/file%03d.go", benchDirs/2, benchSubdirs/2, benchFiles/2)
+	node := fileTree.GetNode(deepPath)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := fileTree.DeleteNode(deepPath); err != nil {
+			b.Fatal(err)
+		}
+		fileTree.AddNode(node)
+	}
+}
+
+func BenchmarkClone(b *testing.B) {
+	fileTree := buildBenchTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fileTree.Clone()
+	}
+}
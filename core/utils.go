@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 )
 
@@ -17,82 +16,41 @@ func FileExists(filename string) bool {
 	return !info.IsDir()
 }
 
-// ---------- GetParentPath ----------
-func getParentPath(path string) string {
-	return path[:strings.LastIndex(path, "/")]
-}
-
 // ---------- GetDfsOrder ----------
+// GetDfsOrder lists every node with children before their folder and
+// siblings in name order
 func GetDfsOrder(ft *FileTree) []*PathNode {
-	var result []*PathNode
-	visited := make(map[string]bool)
-
-	var dfs func(path string)
-	dfs = func(path string) {
-		if visited[path] {
-			return
-		}
-		visited[path] = true
-
-		node, exists := ft.Nodes[path]
-		if !exists {
-			return
-		}
+	ft.loadAll()
 
-		// For directories, visit children first
-		if node.IsDir {
-			children := getChildrenPaths(ft, path)
-			sort.Strings(children) // Sort children for consistent ordering
-			for _, childPath := range children {
-				dfs(childPath)
-			}
+	result := make([]*PathNode, 0, ft.size)
+	ft.root.walk("", nil, func(_ string, t *trieNode) {
+		if t.node != nil {
+			result = append(result, t.node)
 		}
-
-		// Add the node to result after visiting children
-		result = append(result, node)
-	}
-
-	// Get and sort top-level paths
-	topLevelPaths := getTopLevelPaths(ft)
-	sort.Strings(topLevelPaths)
-
-	// Start DFS from each top-level path
-	for _, path := range topLevelPaths {
-		dfs(path)
-	}
-
+	})
 	return result
 }
 
-// ---------- getChildrenPaths ----------
-func getChildrenPaths(ft *FileTree, parentPath string) []string {
-	var children []string
-	for path := range ft.Nodes {
-		if filepath.Dir(path) == parentPath && path != parentPath {
-			children = append(children, path)
-		}
-	}
-	return children
-}
-
-// ---------- getTopLevelPaths ----------
-func getTopLevelPaths(ft *FileTree) []string {
-	var topLevelPaths []string
-	for path := range ft.Nodes {
-		if !strings.Contains(path, string(filepath.Separator)) {
-			topLevelPaths = append(topLevelPaths, path)
-		}
-	}
-	return topLevelPaths
-}
-
 // ---------- PrintUsage ----------
 func PrintUsage() {
 	fmt.Println("Usage: filetree <command> [arguments]")
 	fmt.Println("\nAvailable commands:")
-	fmt.Println("  init                  Initialize a new filetree.yaml")
+	fmt.Println("  init [--pack go,node,python,rust]  Initialize a new filetree.yaml, pre-filling conventional paths")
 	fmt.Println("  update                Update the existing filetree.yaml")
-	fmt.Println("  desc <path> <description>  Add or update description for a path")
+	fmt.Println("  desc [--pending] <path> <description>  Add or update description for a path, --pending keeps one for a path not on main yet")
+	fmt.Println("  explain <path>        Show where the description of a path comes from and which rules match it")
+	fmt.Println("  suggest [path] [--list]  Review descriptions proposed from package comments, READMEs and file headers")
+	fmt.Println("  mv [--force] <src> <dst>  Move a path with git mv and carry its descriptions along")
+	fmt.Println("  status                Show sync state and warn about pending descriptions waiting too long")
+	fmt.Println("  export [--format json|csv|toml] [--output file] [--expand]  Export all descriptions")
+	fmt.Println("  import [--merge] [--dry-run] <file>  Import descriptions from a json, csv or toml export")
+	fmt.Println("  render [--format markdown] [--inject README.md]  Render the annotated tree")
+	fmt.Println("  preview --html <dir>  Write an HTML preview of the GitHub landing page")
+	fmt.Println("  tree [path] [--undescribed] [--depth n] [--dirs-only] [--with-history]  Show descriptions in the terminal")
+	fmt.Println("  stale [--confirm <path>...]  List possibly stale descriptions, or keep them as they are")
+	fmt.Println("  tombstones [--purge [path...]]  List or forget descriptions of deleted paths")
+	fmt.Println("  pending [--drop <path>...]  List or forget descriptions of paths not on main yet")
+	fmt.Println("  shard <depth>         Split filetree.yaml into one file per directory at depth (0 to merge)")
 }
 
 // ---------- AddLineToFile ----------
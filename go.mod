@@ -2,4 +2,7 @@ module github.com/TyPeterson/Gittier
 
 go 1.22.3
 
-require gopkg.in/yaml.v2 v2.4.0
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v2 v2.4.0
+)
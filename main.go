@@ -17,15 +17,41 @@ func main() {
 	var err error = nil
 	switch os.Args[1] {
 	case "init":
-		err = cmd.Init()
+		err = cmd.Init(os.Args[2:])
 	case "sync":
 		err = cmd.Sync()
 	case "desc":
-		if len(os.Args) < 4 {
-			fmt.Println("Usage: filetree desc <path> <description>")
+		err = cmd.Desc(os.Args[2:])
+	case "shard":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: filetree shard <depth>")
 			os.Exit(1)
 		}
-		err = cmd.Desc(os.Args[2], os.Args[3], true)
+		err = cmd.Shard(os.Args[2])
+	case "export":
+		err = cmd.Export(os.Args[2:])
+	case "import":
+		err = cmd.Import(os.Args[2:])
+	case "render":
+		err = cmd.Render(os.Args[2:])
+	case "preview":
+		err = cmd.Preview(os.Args[2:])
+	case "tree":
+		err = cmd.Tree(os.Args[2:])
+	case "stale":
+		err = cmd.Stale(os.Args[2:])
+	case "tombstones":
+		err = cmd.Tombstones(os.Args[2:])
+	case "explain":
+		err = cmd.Explain(os.Args[2:])
+	case "suggest":
+		err = cmd.Suggest(os.Args[2:])
+	case "mv":
+		err = cmd.Mv(os.Args[2:])
+	case "pending":
+		err = cmd.Pending(os.Args[2:])
+	case "status":
+		err = cmd.Status()
 	case "commit":
 		err = cmd.Commit()
 	case "clean":